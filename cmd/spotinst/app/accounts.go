@@ -0,0 +1,38 @@
+package app
+
+import (
+	"context"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"os"
+	"spotinfo/pkg/options"
+	"spotinfo/pkg/spot_analyze/aws"
+)
+
+func NewAccountsCommand(ctx context.Context, opts *options.SpotinstOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:          "accounts",
+		Short:        "list the spotinst accounts the token can see, pick one with --spotinst-account",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			accounts, err := aws.ListSpotinstAccounts(ctx, aws.NewSpotinstAccount(opts, aws.NewCache(opts)))
+			if err != nil {
+				return err
+			}
+			t := table.NewWriter()
+			t.SetOutputMirror(os.Stdout)
+			t.AppendHeader(table.Row{"Account ID", "Name", "Organization ID", ""})
+			for _, account := range accounts {
+				selected := ""
+				if account.AccountId == opts.SpotinstAccount {
+					selected = "*"
+				}
+				t.AppendRow(table.Row{account.AccountId, account.Name, account.OrganizationId, selected})
+			}
+			t.SetStyle(table.StyleLight)
+			t.Render()
+			return nil
+		},
+	}
+}
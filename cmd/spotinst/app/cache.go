@@ -0,0 +1,84 @@
+package app
+
+import (
+	"context"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"os"
+	"spotinfo/pkg/cache"
+	"spotinfo/pkg/options"
+	"spotinfo/pkg/spot_analyze/aws"
+	"time"
+)
+
+func NewCacheCommand(ctx context.Context, opts *options.SpotinstOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "manage the feed cache",
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:          "ls",
+		Short:        "list cached feeds",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return listCache(aws.NewCache(opts))
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:          "clear [feed...]",
+		Short:        "remove cached feeds, all of them when no feed is given",
+		ValidArgs:    cache.Feeds,
+		Args:         cobra.OnlyValidArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return aws.NewCache(opts).Clear(args...)
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:          "refresh [feed...]",
+		Short:        "fetch feeds and store them in the cache, advisor and price when no feed is given",
+		ValidArgs:    cache.Feeds,
+		Args:         cobra.OnlyValidArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				args = []string{cache.AdvisorFeed, cache.PriceFeed}
+			}
+			c := aws.NewCache(opts)
+			http := aws.NewHTTPSource()
+			http.Spotinst = aws.NewSpotinstAccount(opts, c)
+			for _, feed := range args {
+				if http.Spotinst.Tokens == nil && feed == cache.ScoreFeed {
+					return aws.ErrNoCredentials
+				}
+			}
+			src := aws.NewCachedSource(http, c, 0)
+			src.Refresh = true
+			return aws.NewAnalyzer(src).Load(ctx, args...)
+		},
+	})
+	return cmd
+}
+
+func listCache(c *cache.Cache) error {
+	entries, err := c.List()
+	if err != nil {
+		return err
+	}
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.SetTitle(c.Dir)
+	t.AppendHeader(table.Row{"Key", "Feed", "Fetched At", "Age", "TTL", "Status"})
+	for _, e := range entries {
+		status := "fresh"
+		if c.Expired(e) {
+			status = "expired"
+		}
+		t.AppendRow(table.Row{e.Key, e.Feed, e.FetchedAt.Local().Format(time.RFC3339),
+			e.Age().Round(time.Minute), c.TTL[e.Feed], status})
+	}
+	t.SetStyle(table.StyleLight)
+	t.Render()
+	return nil
+}
@@ -0,0 +1,56 @@
+package app
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+func renderCSV(w io.Writer, r *report) error {
+	return writeDelimited(w, r, ',')
+}
+
+func renderTSV(w io.Writer, r *report) error {
+	return writeDelimited(w, r, '\t')
+}
+
+// writeDelimited write the table columns and rows as delimited records with a header record,
+// values are unformatted so spreadsheets read them as numbers
+func writeDelimited(w io.Writer, r *report, comma rune) error {
+	columns := reportColumns(r)
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	record := make([]string, len(columns))
+	for i, c := range columns {
+		record[i] = c.Header
+	}
+	if err := cw.Write(record); err != nil {
+		return errors.Wrap(err, "failed to write advices")
+	}
+	for _, cells := range reportRows(r, columns) {
+		for i, cell := range cells {
+			record[i] = formatCell(cell)
+		}
+		if err := cw.Write(record); err != nil {
+			return errors.Wrap(err, "failed to write advices")
+		}
+	}
+	cw.Flush()
+	return errors.Wrap(cw.Error(), "failed to write advices")
+}
+
+// formatCell missing values are empty, floats use the shortest exact representation
+func formatCell(cell interface{}) string {
+	switch v := cell.(type) {
+	case nil:
+		return ""
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(cell)
+}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"bytes"
+	"spotinfo/pkg/known"
+	"spotinfo/pkg/models"
+	"testing"
+)
+
+func TestRenderCSV(t *testing.T) {
+	r := testReport()
+	r.Advices = append(r.Advices, models.Advice{
+		Region:   "us-east-1",
+		Instance: "c5.large",
+		Range:    models.InterruptionRange{Label: "5-10%", Min: 6, Max: 11},
+		Savings:  50,
+		Info:     models.TypeInfo{Cores: 2, RAM: 4},
+		Price:    0.035,
+		Score:    map[string]models.LifetimeScores{"us-east-1b": {}},
+	})
+	var buf bytes.Buffer
+	if err := renderCSV(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	want := `Availability Zone,Instance Info,vCPU,Memory GiB,Savings over On-Demand,Frequency of interruption,Spot Market Score,USD/Hour
+us-east-1b,c5.large,2,4,50,5-10%,,0.035
+us-east-1a,m5.large,2,8,70,<5%,80,0.04
+us-east-1b,m5.large,2,8,70,<5%,40,0.04
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRenderCSVUnscored(t *testing.T) {
+	r := testReport()
+	r.Advices = append(r.Advices, models.Advice{Region: "us-east-1", Instance: "t3.micro", Savings: 60,
+		Range: models.InterruptionRange{Label: "10-15%", Min: 12, Max: 16}, Info: models.TypeInfo{Cores: 2, RAM: 1},
+		Price: 0.003, Score: map[string]models.LifetimeScores{}})
+	tests := []struct {
+		name  string
+		zones map[string][]string
+		want  string
+	}{
+		{name: "no zone list", want: `Availability Zone,Instance Info,vCPU,Memory GiB,Savings over On-Demand,Frequency of interruption,Spot Market Score,USD/Hour
+,t3.micro,2,1,60,10-15%,,0.003
+us-east-1a,m5.large,2,8,70,<5%,80,0.04
+us-east-1b,m5.large,2,8,70,<5%,40,0.04
+`},
+		{name: "zone list", zones: map[string][]string{"us-east-1": {"us-east-1a", "us-east-1b", "us-east-1c"}},
+			want: `Availability Zone,Instance Info,vCPU,Memory GiB,Savings over On-Demand,Frequency of interruption,Spot Market Score,USD/Hour
+us-east-1a,t3.micro,2,1,60,10-15%,,0.003
+us-east-1b,t3.micro,2,1,60,10-15%,,0.003
+us-east-1c,t3.micro,2,1,60,10-15%,,0.003
+us-east-1a,m5.large,2,8,70,<5%,80,0.04
+us-east-1b,m5.large,2,8,70,<5%,40,0.04
+us-east-1c,m5.large,2,8,70,<5%,,0.04
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r.Zones = tt.zones
+			var buf bytes.Buffer
+			if err := renderCSV(&buf, r); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderTSVNormalMode(t *testing.T) {
+	r := testReport()
+	r.Opts.Mode = known.NormalMode
+	r.PrintRegion = true
+	var buf bytes.Buffer
+	if err := renderTSV(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	want := "Region\tInstance Info\tvCPU\tMemory GiB\tSavings over On-Demand\tFrequency of interruption\tUSD/Hour\n" +
+		"us-east-1\tm5.large\t2\t8\t70\t<5%\t0.04\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestRenderCSVColumns(t *testing.T) {
+	r := testReport()
+	r.Opts.Columns = []string{"score", "az", "instance", "emr", "interruption-min", "interruption-max"}
+	r.Lifetimes = []int{1, 4}
+	var buf bytes.Buffer
+	if err := renderCSV(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	want := `Spot Market Score 1h,Spot Market Score 4h,Availability Zone,Instance Info,EMR,Interruption Min %,Interruption Max %
+80,,us-east-1a,m5.large,true,0,5
+40,,us-east-1b,m5.large,true,0,5
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestValidateColumns(t *testing.T) {
+	tests := []struct {
+		columns []string
+		mode    string
+		valid   bool
+	}{
+		{columns: nil, mode: known.NormalMode, valid: true},
+		{columns: []string{"region", "instance", "price"}, mode: known.NormalMode, valid: true},
+		{columns: []string{"az", "score", "emr"}, mode: known.ScoreMode, valid: true},
+		{columns: []string{"score"}, mode: known.NormalMode},
+		{columns: []string{"cost"}, mode: known.ScoreMode},
+		{columns: []string{"price", "price"}, mode: known.ScoreMode},
+	}
+	for _, tt := range tests {
+		if err := validateColumns(tt.columns, tt.mode); (err == nil) != tt.valid {
+			t.Errorf("%v in %s mode: got %v, want valid %v", tt.columns, tt.mode, err, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"io"
+	"sort"
+	"spotinfo/pkg/known"
+	"spotinfo/pkg/models"
+	"spotinfo/pkg/recommend"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// field names of the spotinst documents follow the spotinst API
+type elastigroupAvailabilityZone struct {
+	Name string `json:"name"`
+	// SubnetIds left for the user to fill in
+	SubnetIds []string `json:"subnetIds"`
+}
+
+type elastigroupInstanceTypes struct {
+	OnDemand string   `json:"ondemand"`
+	Spot     []string `json:"spot"`
+}
+
+type elastigroupCompute struct {
+	Product           string                        `json:"product"`
+	InstanceTypes     elastigroupInstanceTypes      `json:"instanceTypes"`
+	AvailabilityZones []elastigroupAvailabilityZone `json:"availabilityZones"`
+}
+
+type elastigroupGroup struct {
+	Region  string             `json:"region"`
+	Compute elastigroupCompute `json:"compute"`
+}
+
+// elastigroupConfig --output elastigroup document, the group part of an elastigroup create or update request
+type elastigroupConfig struct {
+	Group elastigroupGroup `json:"group"`
+}
+
+type oceanInstanceTypes struct {
+	Whitelist []string `json:"whitelist"`
+}
+
+type oceanCompute struct {
+	InstanceTypes oceanInstanceTypes `json:"instanceTypes"`
+}
+
+type oceanCluster struct {
+	Region  string       `json:"region"`
+	Compute oceanCompute `json:"compute"`
+}
+
+// oceanConfig --output ocean document, the cluster part of an ocean create or update request
+type oceanConfig struct {
+	Cluster oceanCluster `json:"cluster"`
+}
+
+// spotinstSelection instance types and AZs reaching the minimum market score
+type spotinstSelection struct {
+	Region    string
+	Product   string
+	Instances []string
+	Zones     []string
+	// OnDemand the instance type ranked first by recommend.Rank, whatever the --sort order
+	OnDemand string
+}
+
+// reportSpotinstSelection the instance types with an AZ scoring at least --min-score over all lifetimes,
+// and these AZs. Any scored AZ counts without --min-score, the instance types must share an architecture
+func reportSpotinstSelection(r *report) (*spotinstSelection, error) {
+	if r.Opts.Mode != known.ScoreMode {
+		return nil, errors.Errorf("the spotinst exports need --mode %s", known.ScoreMode)
+	}
+	region, err := singleRegion(r.Advices)
+	if err != nil {
+		return nil, err
+	}
+	sel := &spotinstSelection{Region: region, Product: known.ScoreProducts[strings.ToLower(r.Opts.Os)]}
+	zones := make(map[string]bool)
+	var arches []string
+	var matches []models.Advice
+	for i := range r.Advices {
+		advice := &r.Advices[i]
+		matched := false
+		for az, score := range advice.Score {
+			if len(score) > 0 && score.Min() > 0 && score.Min() >= r.Opts.MinScore {
+				zones[az] = true
+				matched = true
+			}
+		}
+		if matched {
+			sel.Instances = append(sel.Instances, advice.Instance)
+			arches = append(arches, advice.Type.Arch)
+			matches = append(matches, *advice)
+		}
+	}
+	// the group or cluster image boots a single architecture
+	if err := singleArch(arches); err != nil {
+		return nil, err
+	}
+	if len(sel.Instances) == 0 {
+		return nil, errors.New("no instance type reaches the minimal market score")
+	}
+	for az := range zones {
+		sel.Zones = append(sel.Zones, az)
+	}
+	sort.Strings(sel.Zones)
+	sel.OnDemand = recommend.Rank(matches, recommend.DefaultWeights, 1)[0].Advice.Instance
+	return sel, nil
+}
+
+// renderElastigroup the best ranked instance type is the on-demand one
+func renderElastigroup(w io.Writer, r *report) error {
+	sel, err := reportSpotinstSelection(r)
+	if err != nil {
+		return err
+	}
+	config := elastigroupConfig{Group: elastigroupGroup{
+		Region: sel.Region,
+		Compute: elastigroupCompute{
+			Product:       sel.Product,
+			InstanceTypes: elastigroupInstanceTypes{OnDemand: sel.OnDemand, Spot: sel.Instances},
+		},
+	}}
+	for _, az := range sel.Zones {
+		config.Group.Compute.AvailabilityZones = append(config.Group.Compute.AvailabilityZones,
+			elastigroupAvailabilityZone{Name: az, SubnetIds: []string{}})
+	}
+	return writeJSON(w, config)
+}
+
+// renderOcean ocean launches in the AZs of the cluster subnets, only the instance types are exported
+func renderOcean(w io.Writer, r *report) error {
+	sel, err := reportSpotinstSelection(r)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, oceanConfig{Cluster: oceanCluster{
+		Region:  sel.Region,
+		Compute: oceanCompute{InstanceTypes: oceanInstanceTypes{Whitelist: sel.Instances}},
+	}})
+}
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"spotinfo/pkg/instancetype"
+	"spotinfo/pkg/known"
+	"spotinfo/pkg/models"
+	"testing"
+)
+
+func TestRenderElastigroup(t *testing.T) {
+	r := testReport()
+	r.Opts.MinScore = 50
+	r.Advices = append(r.Advices, models.Advice{Region: "us-east-1", Instance: "c5.large",
+		Score: map[string]models.LifetimeScores{"us-east-1c": {1: 30}}})
+	var buf bytes.Buffer
+	if err := renderElastigroup(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	var config elastigroupConfig
+	if err := json.Unmarshal(buf.Bytes(), &config); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, buf.String())
+	}
+	compute := config.Group.Compute
+	// c5.large and the AZs scoring below 50 are left out
+	if !reflect.DeepEqual(compute.InstanceTypes, elastigroupInstanceTypes{OnDemand: "m5.large", Spot: []string{"m5.large"}}) {
+		t.Errorf("unexpected instance types %+v", compute.InstanceTypes)
+	}
+	if len(compute.AvailabilityZones) != 1 || compute.AvailabilityZones[0].Name != "us-east-1a" {
+		t.Errorf("unexpected zones %+v", compute.AvailabilityZones)
+	}
+	if compute.Product != "Linux/UNIX (Amazon VPC)" || config.Group.Region != "us-east-1" {
+		t.Errorf("unexpected group %+v", config.Group)
+	}
+}
+
+func TestRenderOcean(t *testing.T) {
+	var buf bytes.Buffer
+	if err := renderOcean(&buf, testReport()); err != nil {
+		t.Fatal(err)
+	}
+	var config oceanConfig
+	if err := json.Unmarshal(buf.Bytes(), &config); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, buf.String())
+	}
+	if !reflect.DeepEqual(config.Cluster.Compute.InstanceTypes.Whitelist, []string{"m5.large"}) {
+		t.Errorf("unexpected whitelist %+v", config.Cluster.Compute.InstanceTypes)
+	}
+	r := testReport()
+	r.Opts.Mode = known.NormalMode
+	if err := renderOcean(&buf, r); err == nil {
+		t.Error("expected a score mode error")
+	}
+}
+
+func TestSpotinstSelectionArch(t *testing.T) {
+	r := testReport()
+	r.Advices[0].Type.Arch = instancetype.X8664
+	r.Advices = append(r.Advices, models.Advice{Region: "us-east-1", Instance: "m6g.xlarge",
+		Type:  instancetype.Info{Arch: instancetype.ARM64},
+		Score: map[string]models.LifetimeScores{"us-east-1a": {1: 90}}})
+	var buf bytes.Buffer
+	if err := renderElastigroup(&buf, r); err == nil {
+		t.Error("expected a mixed architectures error")
+	}
+	r.Opts.Arch = instancetype.ARM64
+	r.Advices = r.Advices[1:]
+	if err := renderElastigroup(&buf, r); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRenderElastigroupOnDemand(t *testing.T) {
+	r := testReport()
+	// the interruption:desc order puts the most interrupted type first
+	r.Advices = append([]models.Advice{{Region: "us-east-1", Instance: "c5.xlarge", Savings: 40, Price: 0.1,
+		Range: models.InterruptionRange{Label: ">20%", Min: 23, Max: 100}, Info: models.TypeInfo{Cores: 4},
+		Score: map[string]models.LifetimeScores{"us-east-1a": {1: 60}}}}, r.Advices...)
+	var buf bytes.Buffer
+	if err := renderElastigroup(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	var config elastigroupConfig
+	if err := json.Unmarshal(buf.Bytes(), &config); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, buf.String())
+	}
+	want := elastigroupInstanceTypes{OnDemand: "m5.large", Spot: []string{"c5.xlarge", "m5.large"}}
+	if !reflect.DeepEqual(config.Group.Compute.InstanceTypes, want) {
+		t.Errorf("got %+v, want %+v", config.Group.Compute.InstanceTypes, want)
+	}
+}
@@ -0,0 +1,180 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"sort"
+	"spotinfo/pkg/fleet"
+	"spotinfo/pkg/known"
+	"spotinfo/pkg/models"
+	"spotinfo/pkg/options"
+	"spotinfo/pkg/spot_analyze/aws"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func NewFleetCommand(ctx context.Context, opts *options.SpotinstOptions) *cobra.Command {
+	fleetOpts := options.NewFleetOptions()
+	cmd := &cobra.Command{
+		Use:   "fleet",
+		Short: "compose a diversified spot fleet per region for a target vCPU or memory capacity",
+		Long: "pick the best ranked instance types matching the filters, at most --max-per-family of a family, " +
+			"split --capacity evenly between them and spread their instances over the best scored AZs",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			if err := fleetOpts.Validate(); err != nil {
+				return err
+			}
+			if opts.Mode == known.ScoreMode && opts.Source == known.HTTPSource {
+				if _, err := aws.NewTokenProvider(opts, nil, nil); err != nil {
+					return err
+				}
+			}
+			return RunFleet(ctx, opts, fleetOpts)
+		},
+	}
+	opts.AddFilterFlags(cmd.Flags())
+	fleetOpts.AddFlags(cmd.Flags())
+	opts.AddLaunchTemplateFlags(cmd.Flags())
+	return cmd
+}
+
+func RunFleet(ctx context.Context, opts *options.SpotinstOptions, fleetOpts *options.FleetOptions) error {
+	r, err := loadReport(ctx, opts)
+	if err != nil {
+		return err
+	}
+	spec := fleetOpts.Spec()
+	spec.Arch = opts.Arch
+	fleets := fleet.Compose(r.Advices, spec)
+	switch strings.ToLower(fleetOpts.Output) {
+	case known.JSONOutput:
+		return renderFleetJSON(os.Stdout, r, fleets)
+	case known.ASGPolicyOutput, known.EC2FleetOutput:
+		if len(fleets) != 1 {
+			return errors.Errorf("got %d fleets, auto scaling groups and EC2 fleets are regional, pick one --region", len(fleets))
+		}
+		launch := fleetLaunchSpec(&fleets[0], opts.LaunchTemplate)
+		if strings.ToLower(fleetOpts.Output) == known.EC2FleetOutput {
+			config, err := ec2Fleet(launch)
+			if err != nil {
+				return err
+			}
+			return writeJSON(os.Stdout, config)
+		}
+		policy, err := asgPolicy(launch)
+		if err != nil {
+			return err
+		}
+		return writeJSON(os.Stdout, policy)
+	}
+	printFleetTables(os.Stdout, r, fleets)
+	return nil
+}
+
+// fleetLaunchSpec the launch spec of f, weighted in the fleet unit and launched in the AZs f spreads over
+func fleetLaunchSpec(f *fleet.Fleet, launchTemplate string) *launchSpec {
+	spec := &launchSpec{Region: f.Region, LaunchTemplate: launchTemplate, TargetCapacity: f.Target}
+	for _, m := range f.Members {
+		zones := make([]string, 0, len(m.Zones))
+		for az := range m.Zones {
+			zones = append(zones, az)
+		}
+		sort.Strings(zones)
+		spec.Overrides = append(spec.Overrides, launchOverride{Instance: m.Advice.Instance, Arch: m.Advice.Type.Arch,
+			Weight: m.Weight, Zones: zones})
+	}
+	return spec
+}
+
+// jsonFleets --output json document of the fleet subcommand
+type jsonFleets struct {
+	Metadata jsonFleetMetadata `json:"metadata"`
+	Fleets   []fleet.Fleet     `json:"fleets"`
+}
+
+type jsonFleetMetadata struct {
+	GeneratedAt time.Time           `json:"generated_at"`
+	Feeds       []models.FeedStatus `json:"feeds"`
+	Warnings    []string            `json:"warnings"`
+}
+
+func renderFleetJSON(w io.Writer, r *report, fleets []fleet.Fleet) error {
+	doc := jsonFleets{
+		Metadata: jsonFleetMetadata{GeneratedAt: time.Now().UTC(), Feeds: r.Feeds, Warnings: r.Warnings},
+		Fleets:   fleets,
+	}
+	if doc.Metadata.Feeds == nil {
+		doc.Metadata.Feeds = []models.FeedStatus{}
+	}
+	if doc.Metadata.Warnings == nil {
+		doc.Metadata.Warnings = []string{}
+	}
+	if doc.Fleets == nil {
+		doc.Fleets = []fleet.Fleet{}
+	}
+	return writeJSON(w, doc)
+}
+
+// capacityUnits unit names of the table titles
+var capacityUnits = map[string]string{fleet.VCPUUnit: "vCPU", fleet.MemoryUnit: "GiB"}
+
+// printFleetTables one table per region, the totals go in the title
+func printFleetTables(w io.Writer, r *report, fleets []fleet.Fleet) {
+	if len(fleets) == 0 {
+		fmt.Fprintln(w, "no instance type matches the filters")
+	}
+	for _, f := range fleets {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		unit := capacityUnits[f.Unit]
+		t.SetTitle("%s %s: %g/%g %s, %.4f USD/Hour, interruption %s",
+			f.Region, fleetArch(&f), f.Capacity, f.Target, unit, f.HourlyCost, f.Interruption.Label)
+		t.AppendHeader(table.Row{instanceTypeColumn, vCPUColumn, memoryColumn, "Weight", "Count",
+			"Capacity " + unit, "Cost USD/Hour", interruptionColumn, "Instances per AZ"})
+		for _, m := range f.Members {
+			t.AppendRow(table.Row{m.Advice.Instance, m.Advice.Info.Cores, m.Advice.Info.RAM, m.Weight, m.Count,
+				m.Capacity, fmt.Sprintf("%.4f", m.HourlyCost), m.Advice.Range.Label, formatZones(m.Zones)})
+		}
+		setWarnings(t, r.Warnings)
+		t.SetStyle(table.StyleLight)
+		t.Style().Title.Align = text.AlignCenter
+		t.Render()
+	}
+}
+
+// fleetArch the architecture of f, unknown when no member has a known one
+func fleetArch(f *fleet.Fleet) string {
+	if f.Arch == "" {
+		return "unknown arch"
+	}
+	return f.Arch
+}
+
+// formatZones instances per AZ like us-east-1a:2 us-east-1b:1, any AZ when there are no scores
+func formatZones(zones map[string]int) string {
+	if len(zones) == 0 {
+		return known.AnyZone
+	}
+	names := make([]string, 0, len(zones))
+	for az := range zones {
+		names = append(names, az)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, az := range names {
+		parts = append(parts, fmt.Sprintf("%s:%d", az, zones[az]))
+	}
+	return strings.Join(parts, " ")
+}
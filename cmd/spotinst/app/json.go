@@ -0,0 +1,102 @@
+package app
+
+import (
+	"io"
+	"spotinfo/pkg/models"
+	"time"
+)
+
+// jsonReport --output json document, field names are part of the output contract
+type jsonReport struct {
+	Metadata jsonMetadata    `json:"metadata"`
+	Advices  []models.Advice `json:"advices"`
+}
+
+type jsonMetadata struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	// Feeds when each feed was fetched, empty for snapshot sources
+	Feeds    []models.FeedStatus `json:"feeds"`
+	Filters  jsonFilters         `json:"filters"`
+	Warnings []string            `json:"warnings"`
+}
+
+type jsonFilters struct {
+	Regions          []string `json:"regions"`
+	InstanceType     string   `json:"instance_type"`
+	Os               string   `json:"os"`
+	Mode             string   `json:"mode"`
+	MinCpu           int      `json:"min_cpu"`
+	MaxCpu           int      `json:"max_cpu"`
+	MinMemory        float64  `json:"min_memory"`
+	MaxMemory        float64  `json:"max_memory"`
+	MinMemoryPerCpu  float64  `json:"min_memory_per_cpu"`
+	MaxMemoryPerCpu  float64  `json:"max_memory_per_cpu"`
+	MinSavings       int      `json:"min_savings"`
+	MaxPrice         float64  `json:"max_price"`
+	MaxInterruption  string   `json:"max_interruption"`
+	MinScore         int      `json:"min_score"`
+	ScoreMatch       string   `json:"score_match"`
+	Families         []string `json:"families"`
+	MinGeneration    int      `json:"min_generation"`
+	Arch             string   `json:"arch"`
+	ExcludeBurstable bool     `json:"exclude_burstable"`
+	Lifetimes        []int    `json:"lifetimes"`
+	Sort             string   `json:"sort"`
+	Order            string   `json:"order"`
+}
+
+func renderJSON(w io.Writer, r *report) error {
+	doc := jsonReport{
+		Metadata: jsonMetadata{
+			GeneratedAt: time.Now().UTC(),
+			Feeds:       r.Feeds,
+			Filters: jsonFilters{
+				Regions:          r.Opts.Region,
+				InstanceType:     r.Opts.Type,
+				Os:               r.Opts.Os,
+				Mode:             r.Opts.Mode,
+				MinCpu:           r.Opts.MinCpu,
+				MaxCpu:           r.Opts.MaxCpu,
+				MinMemory:        r.Opts.MinMemory,
+				MaxMemory:        r.Opts.MaxMemory,
+				MinMemoryPerCpu:  r.Opts.MinMemoryPerCpu,
+				MaxMemoryPerCpu:  r.Opts.MaxMemoryPerCpu,
+				MinSavings:       r.Opts.MinSavings,
+				MaxPrice:         r.Opts.MaxPrice,
+				MaxInterruption:  r.Opts.MaxInterruption,
+				MinScore:         r.Opts.MinScore,
+				ScoreMatch:       r.Opts.ScoreMatch,
+				Families:         r.Opts.Families,
+				MinGeneration:    r.Opts.MinGeneration,
+				Arch:             r.Opts.Arch,
+				ExcludeBurstable: r.Opts.ExcludeBurstable,
+				Lifetimes:        r.Lifetimes,
+				Sort:             r.Opts.Sort,
+				Order:            r.Opts.Order,
+			},
+			Warnings: r.Warnings,
+		},
+		Advices: r.Advices,
+	}
+	// consumers get empty lists rather than nulls
+	if doc.Metadata.Feeds == nil {
+		doc.Metadata.Feeds = []models.FeedStatus{}
+	}
+	if doc.Metadata.Warnings == nil {
+		doc.Metadata.Warnings = []string{}
+	}
+	if doc.Advices == nil {
+		doc.Advices = []models.Advice{}
+	}
+	filters := &doc.Metadata.Filters
+	if filters.Regions == nil {
+		filters.Regions = []string{}
+	}
+	if filters.Families == nil {
+		filters.Families = []string{}
+	}
+	if filters.Lifetimes == nil {
+		filters.Lifetimes = []int{}
+	}
+	return writeJSON(w, doc)
+}
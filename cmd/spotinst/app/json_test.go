@@ -0,0 +1,126 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"spotinfo/pkg/known"
+	"spotinfo/pkg/models"
+	"spotinfo/pkg/options"
+	"testing"
+	"time"
+)
+
+func testReport() *report {
+	return &report{
+		Advices: []models.Advice{
+			{
+				Region:   "us-east-1",
+				Instance: "m5.large",
+				Range:    models.InterruptionRange{Label: "<5%", Min: 0, Max: 5},
+				Savings:  70,
+				Info:     models.TypeInfo{Cores: 2, Emr: true, RAM: 8},
+				Price:    0.04,
+				Score:    map[string]models.LifetimeScores{"us-east-1a": {1: 80}, "us-east-1b": {1: 40}},
+			},
+		},
+		Opts: &options.SpotinstOptions{
+			Region: []string{"us-east-1"},
+			Type:   `m5\.large`,
+			Os:     "linux",
+			Mode:   known.ScoreMode,
+			Sort:   "interruption",
+			Order:  "desc",
+		},
+		Lifetimes: []int{1},
+		Feeds:     []models.FeedStatus{{Feed: "advisor", FetchedAt: time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC)}},
+	}
+}
+
+func TestRenderJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := renderJSON(&buf, testReport()); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Metadata struct {
+			Feeds []struct {
+				Feed      string `json:"feed"`
+				FetchedAt string `json:"fetched_at"`
+			} `json:"feeds"`
+			Filters struct {
+				Regions   []string `json:"regions"`
+				Lifetimes []int    `json:"lifetimes"`
+			} `json:"filters"`
+			Warnings []string `json:"warnings"`
+		} `json:"metadata"`
+		Advices []struct {
+			Region string `json:"region"`
+			Range  struct {
+				Label string `json:"label"`
+				Max   int    `json:"max"`
+			} `json:"range"`
+			Info struct {
+				Cores int     `json:"cores"`
+				Emr   bool    `json:"emr"`
+				RAM   float64 `json:"ram_gb"`
+			} `json:"info"`
+			Score map[string]map[string]int `json:"score"`
+		} `json:"advices"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, buf.String())
+	}
+	if len(doc.Advices) != 1 {
+		t.Fatalf("got %d advices, want 1", len(doc.Advices))
+	}
+	advice := doc.Advices[0]
+	if advice.Region != "us-east-1" || advice.Range.Label != "<5%" || advice.Range.Max != 5 {
+		t.Errorf("unexpected advice %+v", advice)
+	}
+	if advice.Info.Cores != 2 || !advice.Info.Emr || advice.Info.RAM != 8 {
+		t.Errorf("unexpected info %+v", advice.Info)
+	}
+	if advice.Score["us-east-1a"]["1"] != 80 {
+		t.Errorf("unexpected score %v", advice.Score)
+	}
+	if len(doc.Metadata.Feeds) != 1 || doc.Metadata.Feeds[0].FetchedAt != "2023-08-01T00:00:00Z" {
+		t.Errorf("unexpected feeds %+v", doc.Metadata.Feeds)
+	}
+	if len(doc.Metadata.Filters.Regions) != 1 || len(doc.Metadata.Filters.Lifetimes) != 1 {
+		t.Errorf("unexpected filters %+v", doc.Metadata.Filters)
+	}
+	if doc.Metadata.Warnings == nil {
+		t.Error("warnings should be an empty list")
+	}
+}
+
+func TestRenderJSONEmptyLists(t *testing.T) {
+	r := testReport()
+	r.Opts.Region, r.Opts.Families, r.Lifetimes = nil, nil, nil
+	var buf bytes.Buffer
+	if err := renderJSON(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Metadata struct {
+			Filters map[string]interface{} `json:"filters"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, buf.String())
+	}
+	for _, name := range []string{"regions", "families", "lifetimes"} {
+		if list, ok := doc.Metadata.Filters[name].([]interface{}); !ok || len(list) != 0 {
+			t.Errorf("%s: got %v, want an empty list", name, doc.Metadata.Filters[name])
+		}
+	}
+}
+
+func TestGetRenderer(t *testing.T) {
+	if _, err := getRenderer("JSON"); err != nil {
+		t.Error(err)
+	}
+	if _, err := getRenderer("yaml"); err == nil {
+		t.Error("expected an invalid output error")
+	}
+}
@@ -0,0 +1,106 @@
+package app
+
+import (
+	"io"
+	"sort"
+	"strconv"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// highInterruption advices interrupted more often than this percentage are left out by --exclude-high-interruption
+const highInterruption = 20
+
+// nodePoolTemplate karpenter.sh/v1 NodePool, values are quoted with strconv.Quote, a valid YAML double quoted scalar
+var nodePoolTemplate = template.Must(template.New("nodepool").Funcs(template.FuncMap{"quote": strconv.Quote}).Parse(
+	`apiVersion: karpenter.sh/v1
+kind: NodePool
+metadata:
+  name: {{ quote .Name }}
+spec:
+  template:
+    spec:
+      nodeClassRef:
+        group: karpenter.k8s.aws
+        kind: EC2NodeClass
+        name: {{ quote .NodeClass }}
+      requirements:
+        - key: karpenter.sh/capacity-type
+          operator: In
+          values:
+            - spot
+        - key: node.kubernetes.io/instance-type
+          operator: In
+          values:
+{{- range .Instances }}
+            - {{ quote . }}
+{{- end }}
+{{- if .Zones }}
+        - key: topology.kubernetes.io/zone
+          operator: In
+          values:
+{{- range .Zones }}
+            - {{ quote . }}
+{{- end }}
+{{- end }}
+`))
+
+type nodePool struct {
+	Name      string
+	NodeClass string
+	Instances []string
+	// Zones empty when the advices have no score, karpenter picks any AZ
+	Zones []string
+}
+
+// reportNodePool the NodePool of the report advices. AZs are rated by the mean score of the instance
+// types, unscored types count as 0, and the --karpenter-zones best ones are required
+func reportNodePool(r *report) (*nodePool, error) {
+	pool := &nodePool{Name: r.Opts.NodePool, NodeClass: r.Opts.NodeClass}
+	if _, err := singleRegion(r.Advices); err != nil {
+		return nil, err
+	}
+	scores := make(map[string]int)
+	for i := range r.Advices {
+		advice := &r.Advices[i]
+		if r.Opts.ExcludeHighInterruption && advice.Range.Min > highInterruption {
+			continue
+		}
+		pool.Instances = append(pool.Instances, advice.Instance)
+		for az, score := range advice.Score {
+			if len(score) > 0 {
+				scores[az] += score.Min()
+			}
+		}
+	}
+	if len(pool.Instances) == 0 {
+		return nil, errors.New("no instance type matches the filters, the NodePool would be empty")
+	}
+	sort.Strings(pool.Instances)
+	for az, total := range scores {
+		if total > 0 {
+			pool.Zones = append(pool.Zones, az)
+		}
+	}
+	// the total is proportional to the mean, every zone has the same instance types
+	sort.Slice(pool.Zones, func(i, j int) bool {
+		if scores[pool.Zones[i]] != scores[pool.Zones[j]] {
+			return scores[pool.Zones[i]] > scores[pool.Zones[j]]
+		}
+		return pool.Zones[i] < pool.Zones[j]
+	})
+	if r.Opts.KarpenterZones > 0 && len(pool.Zones) > r.Opts.KarpenterZones {
+		pool.Zones = pool.Zones[:r.Opts.KarpenterZones]
+	}
+	sort.Strings(pool.Zones)
+	return pool, nil
+}
+
+func renderKarpenter(w io.Writer, r *report) error {
+	pool, err := reportNodePool(r)
+	if err != nil {
+		return err
+	}
+	return errors.Wrap(nodePoolTemplate.Execute(w, pool), "failed to write NodePool")
+}
@@ -0,0 +1,38 @@
+package app
+
+import (
+	"bytes"
+	"spotinfo/pkg/models"
+	"strings"
+	"testing"
+)
+
+func TestRenderKarpenter(t *testing.T) {
+	r := testReport()
+	r.Opts.NodePool, r.Opts.NodeClass = "spot", "default"
+	r.Opts.KarpenterZones = 1
+	r.Opts.ExcludeHighInterruption = true
+	r.Advices = append(r.Advices,
+		models.Advice{Region: "us-east-1", Instance: "c5.large", Range: models.InterruptionRange{Label: "<5%", Max: 5},
+			Score: map[string]models.LifetimeScores{"us-east-1b": {1: 30}, "us-east-1c": {1: 60}}},
+		models.Advice{Region: "us-east-1", Instance: "p3.2xlarge", Range: models.InterruptionRange{Label: ">20%", Min: 23, Max: 100}})
+	var buf bytes.Buffer
+	if err := renderKarpenter(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	// us-east-1a scores 80, us-east-1b 40+30 and us-east-1c 60
+	for _, want := range []string{
+		"kind: NodePool",
+		"- key: karpenter.sh/capacity-type\n          operator: In\n          values:\n            - spot\n",
+		"- key: node.kubernetes.io/instance-type\n          operator: In\n          values:\n            - \"c5.large\"\n            - \"m5.large\"\n",
+		"- key: topology.kubernetes.io/zone\n          operator: In\n          values:\n            - \"us-east-1a\"\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "p3.2xlarge") || strings.Contains(out, "us-east-1b") {
+		t.Errorf("unexpected instance type or zone in\n%s", out)
+	}
+}
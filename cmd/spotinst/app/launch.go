@@ -0,0 +1,239 @@
+package app
+
+import (
+	"io"
+	"math"
+	"sort"
+	"spotinfo/pkg/models"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// maxASGOverrides instance types an auto scaling group accepts
+const maxASGOverrides = 40
+
+// launchOverride an instance type to launch, Weight capacity units of one instance
+type launchOverride struct {
+	Instance string
+	// Arch processor architecture, a launch template boots a single one
+	Arch   string
+	Weight float64
+	// Zones AZs to launch in, any AZ when empty
+	Zones []string
+}
+
+// launchSpec what the asg-policy and ec2-fleet outputs launch, TargetCapacity is 0 when unknown
+type launchSpec struct {
+	Region         string
+	LaunchTemplate string
+	TargetCapacity float64
+	Overrides      []launchOverride
+}
+
+// field names of the AWS documents follow the AWS API, the output is accepted by the aws CLI and CloudFormation
+type launchTemplateSpecification struct {
+	LaunchTemplateName string `json:"LaunchTemplateName"`
+	Version            string `json:"Version"`
+}
+
+type asgOverride struct {
+	InstanceType     string `json:"InstanceType"`
+	WeightedCapacity string `json:"WeightedCapacity"`
+}
+
+type asgLaunchTemplate struct {
+	LaunchTemplateSpecification launchTemplateSpecification `json:"LaunchTemplateSpecification"`
+	Overrides                   []asgOverride               `json:"Overrides"`
+}
+
+type asgInstancesDistribution struct {
+	OnDemandBaseCapacity                int    `json:"OnDemandBaseCapacity"`
+	OnDemandPercentageAboveBaseCapacity int    `json:"OnDemandPercentageAboveBaseCapacity"`
+	SpotAllocationStrategy              string `json:"SpotAllocationStrategy"`
+}
+
+// asgMixedInstancesPolicy --output asg-policy document, e.g. for
+// aws autoscaling create-auto-scaling-group --mixed-instances-policy file://policy.json
+type asgMixedInstancesPolicy struct {
+	LaunchTemplate        asgLaunchTemplate        `json:"LaunchTemplate"`
+	InstancesDistribution asgInstancesDistribution `json:"InstancesDistribution"`
+}
+
+type ec2FleetOverride struct {
+	InstanceType     string  `json:"InstanceType"`
+	AvailabilityZone string  `json:"AvailabilityZone,omitempty"`
+	WeightedCapacity float64 `json:"WeightedCapacity"`
+}
+
+type ec2FleetLaunchTemplateConfig struct {
+	LaunchTemplateSpecification launchTemplateSpecification `json:"LaunchTemplateSpecification"`
+	Overrides                   []ec2FleetOverride          `json:"Overrides"`
+}
+
+type ec2FleetTargetCapacity struct {
+	TotalTargetCapacity       int    `json:"TotalTargetCapacity"`
+	DefaultTargetCapacityType string `json:"DefaultTargetCapacityType"`
+}
+
+type ec2FleetSpotOptions struct {
+	AllocationStrategy string `json:"AllocationStrategy"`
+}
+
+// ec2FleetConfig --output ec2-fleet document, e.g. for aws ec2 create-fleet --cli-input-json file://fleet.json
+type ec2FleetConfig struct {
+	LaunchTemplateConfigs       []ec2FleetLaunchTemplateConfig `json:"LaunchTemplateConfigs"`
+	TargetCapacitySpecification ec2FleetTargetCapacity         `json:"TargetCapacitySpecification"`
+	SpotOptions                 ec2FleetSpotOptions            `json:"SpotOptions"`
+}
+
+const (
+	latestVersion         = "$Latest"
+	spotCapacityType      = "spot"
+	priceCapacityStrategy = "price-capacity-optimized"
+)
+
+// reportLaunchSpec the launch spec of the report advices, weighted by vCPUs and launched in the AZs with a score
+func reportLaunchSpec(r *report) (*launchSpec, error) {
+	region, err := singleRegion(r.Advices)
+	if err != nil {
+		return nil, err
+	}
+	spec := &launchSpec{Region: region, LaunchTemplate: r.Opts.LaunchTemplate, TargetCapacity: float64(r.Opts.TargetCapacity)}
+	for i := range r.Advices {
+		advice := &r.Advices[i]
+		zones := make([]string, 0, len(advice.Score))
+		for az, score := range advice.Score {
+			if len(score) > 0 {
+				zones = append(zones, az)
+			}
+		}
+		sort.Strings(zones)
+		spec.Overrides = append(spec.Overrides, launchOverride{Instance: advice.Instance, Arch: advice.Type.Arch,
+			Weight: float64(advice.Info.Cores), Zones: zones})
+	}
+	return spec, nil
+}
+
+// checkArch the overrides share the launch template, they must share its architecture
+func checkArch(spec *launchSpec) error {
+	arches := make([]string, 0, len(spec.Overrides))
+	for _, o := range spec.Overrides {
+		arches = append(arches, o.Arch)
+	}
+	return singleArch(arches)
+}
+
+// singleRegion the region of advices, empty without advices. The exported groups, fleets and
+// pools are regional, advices of several regions are an error
+func singleRegion(advices []models.Advice) (string, error) {
+	region := ""
+	for i := range advices {
+		if region != "" && advices[i].Region != region {
+			return "", errors.Errorf("advices span regions %s and %s, the output is regional, pick one --region",
+				region, advices[i].Region)
+		}
+		region = advices[i].Region
+	}
+	return region, nil
+}
+
+// singleArch error when arches, unknown ones left out, hold more than one architecture
+func singleArch(arches []string) error {
+	found := make(map[string]bool)
+	for _, arch := range arches {
+		if arch != "" {
+			found[arch] = true
+		}
+	}
+	if len(found) < 2 {
+		return nil
+	}
+	names := make([]string, 0, len(found))
+	for arch := range found {
+		names = append(names, arch)
+	}
+	sort.Strings(names)
+	return errors.Errorf("instance types span architectures %s, an image boots a single one, pick it with --arch",
+		strings.Join(names, " and "))
+}
+
+func asgPolicy(spec *launchSpec) (*asgMixedInstancesPolicy, error) {
+	if err := checkArch(spec); err != nil {
+		return nil, err
+	}
+	if len(spec.Overrides) > maxASGOverrides {
+		return nil, errors.Errorf("%d instance types, an auto scaling group accepts at most %d, narrow the filters",
+			len(spec.Overrides), maxASGOverrides)
+	}
+	policy := &asgMixedInstancesPolicy{
+		LaunchTemplate: asgLaunchTemplate{
+			LaunchTemplateSpecification: launchTemplateSpecification{LaunchTemplateName: spec.LaunchTemplate, Version: latestVersion},
+			Overrides:                   []asgOverride{},
+		},
+		InstancesDistribution: asgInstancesDistribution{SpotAllocationStrategy: priceCapacityStrategy},
+	}
+	for _, o := range spec.Overrides {
+		policy.LaunchTemplate.Overrides = append(policy.LaunchTemplate.Overrides, asgOverride{
+			InstanceType:     o.Instance,
+			WeightedCapacity: strconv.FormatFloat(o.Weight, 'f', -1, 64),
+		})
+	}
+	return policy, nil
+}
+
+// ec2Fleet one override per instance type and AZ, EC2 Fleet requires a target capacity
+func ec2Fleet(spec *launchSpec) (*ec2FleetConfig, error) {
+	if err := checkArch(spec); err != nil {
+		return nil, err
+	}
+	if spec.TargetCapacity <= 0 {
+		return nil, errors.New("EC2 Fleet needs a target capacity, set --target-capacity")
+	}
+	config := &ec2FleetConfig{
+		LaunchTemplateConfigs: []ec2FleetLaunchTemplateConfig{{
+			LaunchTemplateSpecification: launchTemplateSpecification{LaunchTemplateName: spec.LaunchTemplate, Version: latestVersion},
+			Overrides:                   []ec2FleetOverride{},
+		}},
+		TargetCapacitySpecification: ec2FleetTargetCapacity{
+			TotalTargetCapacity:       int(math.Ceil(spec.TargetCapacity)),
+			DefaultTargetCapacityType: spotCapacityType,
+		},
+		SpotOptions: ec2FleetSpotOptions{AllocationStrategy: priceCapacityStrategy},
+	}
+	overrides := &config.LaunchTemplateConfigs[0].Overrides
+	for _, o := range spec.Overrides {
+		if len(o.Zones) == 0 {
+			*overrides = append(*overrides, ec2FleetOverride{InstanceType: o.Instance, WeightedCapacity: o.Weight})
+		}
+		for _, az := range o.Zones {
+			*overrides = append(*overrides, ec2FleetOverride{InstanceType: o.Instance, AvailabilityZone: az, WeightedCapacity: o.Weight})
+		}
+	}
+	return config, nil
+}
+
+func renderASGPolicy(w io.Writer, r *report) error {
+	spec, err := reportLaunchSpec(r)
+	if err != nil {
+		return err
+	}
+	policy, err := asgPolicy(spec)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, policy)
+}
+
+func renderEC2Fleet(w io.Writer, r *report) error {
+	spec, err := reportLaunchSpec(r)
+	if err != nil {
+		return err
+	}
+	config, err := ec2Fleet(spec)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, config)
+}
@@ -0,0 +1,82 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"spotinfo/pkg/instancetype"
+	"spotinfo/pkg/models"
+	"testing"
+)
+
+func TestRenderASGPolicy(t *testing.T) {
+	r := testReport()
+	r.Opts.LaunchTemplate = "web"
+	var buf bytes.Buffer
+	if err := renderASGPolicy(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	var policy asgMixedInstancesPolicy
+	if err := json.Unmarshal(buf.Bytes(), &policy); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, buf.String())
+	}
+	overrides := policy.LaunchTemplate.Overrides
+	if len(overrides) != 1 || overrides[0].InstanceType != "m5.large" || overrides[0].WeightedCapacity != "2" {
+		t.Errorf("unexpected overrides %+v", overrides)
+	}
+	if policy.LaunchTemplate.LaunchTemplateSpecification.LaunchTemplateName != "web" {
+		t.Errorf("unexpected launch template %+v", policy.LaunchTemplate.LaunchTemplateSpecification)
+	}
+}
+
+func TestRenderEC2Fleet(t *testing.T) {
+	r := testReport()
+	var buf bytes.Buffer
+	if err := renderEC2Fleet(&buf, r); err == nil {
+		t.Error("expected a missing target capacity error")
+	}
+	r.Opts.TargetCapacity = 8
+	buf.Reset()
+	if err := renderEC2Fleet(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	var config ec2FleetConfig
+	if err := json.Unmarshal(buf.Bytes(), &config); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, buf.String())
+	}
+	// one override per scored AZ
+	overrides := config.LaunchTemplateConfigs[0].Overrides
+	if len(overrides) != 2 || overrides[0].AvailabilityZone != "us-east-1a" || overrides[1].WeightedCapacity != 2 {
+		t.Errorf("unexpected overrides %+v", overrides)
+	}
+	want := ec2FleetTargetCapacity{TotalTargetCapacity: 8, DefaultTargetCapacityType: spotCapacityType}
+	if config.TargetCapacitySpecification != want {
+		t.Errorf("got target capacity %+v, want %+v", config.TargetCapacitySpecification, want)
+	}
+}
+
+func TestLaunchSpecArch(t *testing.T) {
+	r := testReport()
+	r.Advices[0].Type.Arch = instancetype.X8664
+	r.Advices = append(r.Advices, models.Advice{Region: "us-east-1", Instance: "m6g.large",
+		Info: models.TypeInfo{Cores: 2}, Type: instancetype.Info{Arch: instancetype.ARM64}})
+	var buf bytes.Buffer
+	if err := renderASGPolicy(&buf, r); err == nil {
+		t.Error("expected a mixed architectures error")
+	}
+	r.Opts.TargetCapacity = 8
+	if err := renderEC2Fleet(&buf, r); err == nil {
+		t.Error("expected a mixed architectures error")
+	}
+}
+
+func TestLaunchSpecSingleRegion(t *testing.T) {
+	r := testReport()
+	r.Advices = append(r.Advices, models.Advice{Region: "eu-west-1", Instance: "m5.large", Info: models.TypeInfo{Cores: 2}})
+	var buf bytes.Buffer
+	for name, render := range map[string]renderer{"asg-policy": renderASGPolicy, "karpenter": renderKarpenter,
+		"tfvars": renderTFVars, "elastigroup": renderElastigroup} {
+		if err := render(&buf, r); err == nil {
+			t.Errorf("%s: expected a single region error", name)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package app
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// markdownCells plain cells, markdown has no colors
+var markdownCells = cellStyle{
+	Savings: func(val interface{}) string { return fmt.Sprintf("%v%%", val) },
+}
+
+// htmlCells escaped cells, scores are wrapped in a span with a score-<level> class
+var htmlCells = cellStyle{
+	Score: func(val interface{}) string {
+		score, ok := val.(int)
+		if !ok {
+			return html.EscapeString(fmt.Sprint(val))
+		}
+		return fmt.Sprintf(`<span class="score score-%s">%d</span>`, scoreLevel(score), score)
+	},
+	Savings: func(val interface{}) string { return html.EscapeString(fmt.Sprintf("%v%%", val)) },
+	Other:   func(val interface{}) string { return html.EscapeString(fmt.Sprint(val)) },
+}
+
+func renderMarkdown(w io.Writer, r *report) error {
+	t := newAdvicesTable(r, markdownCells)
+	t.SetCaption(strings.Join(r.Warnings, "; "))
+	_, err := fmt.Fprintln(w, t.RenderMarkdown())
+	return errors.Wrap(err, "failed to write advices")
+}
+
+func renderHTML(w io.Writer, r *report) error {
+	t := newAdvicesTable(r, htmlCells)
+	// cells are escaped by the transformers, the score spans must stay markup
+	t.Style().HTML.EscapeText = false
+	t.SetCaption(html.EscapeString(strings.Join(r.Warnings, "; ")))
+	_, err := fmt.Fprintln(w, t.RenderHTML())
+	return errors.Wrap(err, "failed to write advices")
+}
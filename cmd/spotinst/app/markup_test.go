@@ -0,0 +1,48 @@
+package app
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	r := testReport()
+	r.Warnings = []string{"advisor data is 30 hours old"}
+	var buf bytes.Buffer
+	if err := renderMarkdown(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"| us-east-1a | m5.large | 2 | 8 | 70% | <5% | 80 | 0.04 |",
+		"_advisor data is 30 hours old_",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "\x1b[") {
+		t.Errorf("unexpected ANSI escapes in\n%s", got)
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := renderHTML(&buf, testReport()); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		`<span class="score score-high">80</span>`,
+		`<span class="score score-low">40</span>`,
+		"<td>&lt;5%</td>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "\x1b[") {
+		t.Errorf("unexpected ANSI escapes in\n%s", got)
+	}
+}
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"github.com/bytedance/sonic"
+	"io"
+	"sort"
+	"spotinfo/pkg/known"
+	"spotinfo/pkg/models"
+	"spotinfo/pkg/options"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// report everything a renderer needs to print the advices
+type report struct {
+	Advices   []models.Advice
+	Opts      *options.SpotinstOptions
+	Lifetimes []int
+	// PrintRegion advices span several regions
+	PrintRegion bool
+	Warnings    []string
+	Feeds       []models.FeedStatus
+	// Zones AZs of every region in score mode, each gets a row whether scored or not
+	Zones map[string][]string
+}
+
+// renderer print a report in one output format
+type renderer func(w io.Writer, r *report) error
+
+var renderers = map[string]renderer{
+	known.TableOutput:       renderTable,
+	known.JSONOutput:        renderJSON,
+	known.CSVOutput:         renderCSV,
+	known.TSVOutput:         renderTSV,
+	known.MarkdownOutput:    renderMarkdown,
+	known.HTMLOutput:        renderHTML,
+	known.ASGPolicyOutput:   renderASGPolicy,
+	known.EC2FleetOutput:    renderEC2Fleet,
+	known.KarpenterOutput:   renderKarpenter,
+	known.TFVarsOutput:      renderTFVars,
+	known.HCLJSONOutput:     renderHCLJSON,
+	known.ElastigroupOutput: renderElastigroup,
+	known.OceanOutput:       renderOcean,
+}
+
+// outputFormats the supported --output values
+func outputFormats() []string {
+	formats := make([]string, 0, len(renderers))
+	for format := range renderers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// getRenderer the renderer of format
+func getRenderer(format string) (renderer, error) {
+	render, ok := renderers[strings.ToLower(format)]
+	if !ok {
+		return nil, errors.Errorf("invalid output %q, must be %s", format, strings.Join(outputFormats(), "|"))
+	}
+	return render, nil
+}
+
+// writeJSON write doc indented, the std config sorts map keys so the output is stable
+func writeJSON(w io.Writer, doc interface{}) error {
+	content, err := sonic.ConfigStd.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to encode output")
+	}
+	_, err = w.Write(append(content, '\n'))
+	return errors.Wrap(err, "failed to write output")
+}
+
+func renderTable(w io.Writer, r *report) error {
+	printAdvicesTable(w, r)
+	return nil
+}
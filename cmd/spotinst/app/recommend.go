@@ -0,0 +1,129 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"spotinfo/pkg/known"
+	"spotinfo/pkg/models"
+	"spotinfo/pkg/options"
+	"spotinfo/pkg/recommend"
+	"spotinfo/pkg/spot_analyze/aws"
+	"strings"
+	"time"
+)
+
+func NewRecommendCommand(ctx context.Context, opts *options.SpotinstOptions) *cobra.Command {
+	recOpts := options.NewRecommendOptions()
+	cmd := &cobra.Command{
+		Use:   "recommend",
+		Short: "recommend the best spot instance types per region for vCPU/memory requirements",
+		Long: "rank the instance types matching the filters, e.g. --min-cpu 4 --min-memory 16, by a weighted composite of " +
+			"savings, frequency of interruption, price per vCPU and the best AZ spotinst market score",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			if err := recOpts.Validate(); err != nil {
+				return err
+			}
+			if opts.Mode == known.ScoreMode && opts.Source == known.HTTPSource {
+				if _, err := aws.NewTokenProvider(opts, nil, nil); err != nil {
+					return err
+				}
+			}
+			return RunRecommend(ctx, opts, recOpts)
+		},
+	}
+	opts.AddFilterFlags(cmd.Flags())
+	recOpts.AddFlags(cmd.Flags())
+	return cmd
+}
+
+func RunRecommend(ctx context.Context, opts *options.SpotinstOptions, recOpts *options.RecommendOptions) error {
+	weights, err := recommend.ParseWeights(recOpts.Weights)
+	if err != nil {
+		return err
+	}
+	r, err := loadReport(ctx, opts)
+	if err != nil {
+		return err
+	}
+	recs := recommend.Rank(r.Advices, weights, recOpts.Top)
+	if strings.ToLower(recOpts.Output) == known.JSONOutput {
+		return renderRecommendJSON(os.Stdout, r, weights, recOpts.Top, recs)
+	}
+	printRecommendTable(os.Stdout, r, recs)
+	return nil
+}
+
+// jsonRecommendations --output json document of the recommend subcommand
+type jsonRecommendations struct {
+	Metadata        jsonRecommendMetadata      `json:"metadata"`
+	Recommendations []recommend.Recommendation `json:"recommendations"`
+}
+
+type jsonRecommendMetadata struct {
+	GeneratedAt time.Time           `json:"generated_at"`
+	Feeds       []models.FeedStatus `json:"feeds"`
+	Weights     recommend.Weights   `json:"weights"`
+	Top         int                 `json:"top"`
+	Warnings    []string            `json:"warnings"`
+}
+
+func renderRecommendJSON(w io.Writer, r *report, weights recommend.Weights, top int, recs []recommend.Recommendation) error {
+	doc := jsonRecommendations{
+		Metadata: jsonRecommendMetadata{
+			GeneratedAt: time.Now().UTC(),
+			Feeds:       r.Feeds,
+			Weights:     weights,
+			Top:         top,
+			Warnings:    r.Warnings,
+		},
+		Recommendations: recs,
+	}
+	if doc.Metadata.Feeds == nil {
+		doc.Metadata.Feeds = []models.FeedStatus{}
+	}
+	if doc.Metadata.Warnings == nil {
+		doc.Metadata.Warnings = []string{}
+	}
+	if doc.Recommendations == nil {
+		doc.Recommendations = []recommend.Recommendation{}
+	}
+	return writeJSON(w, doc)
+}
+
+func printRecommendTable(w io.Writer, r *report, recs []recommend.Recommendation) {
+	t := table.NewWriter()
+	t.SetOutputMirror(w)
+	t.AppendHeader(table.Row{"Rank", regionColumn, instanceTypeColumn, vCPUColumn, memoryColumn, savingsColumn,
+		interruptionColumn, priceColumn, azColumn, scoreColumn, "Composite", "Why"})
+	for _, rec := range recs {
+		zone, score := interface{}("-"), interface{}("-")
+		if rec.BestZone != "" {
+			zone, score = rec.BestZone, rec.BestScore
+		}
+		price := interface{}("-")
+		if rec.Advice.Price > 0 {
+			price = rec.Advice.Price
+		}
+		t.AppendRow(table.Row{rec.Rank, rec.Advice.Region, rec.Advice.Instance, rec.Advice.Info.Cores, rec.Advice.Info.RAM,
+			rec.Advice.Savings, rec.Advice.Range.Label, price, zone, score, fmt.Sprintf("%.3f", rec.Composite), rec.Reason})
+	}
+	setWarnings(t, r.Warnings)
+	t.SetColumnConfigs([]table.ColumnConfig{
+		{Name: regionColumn, AutoMerge: true, Align: text.AlignLeft},
+		{Name: savingsColumn, Transformer: ansiCells.Savings},
+		{Name: scoreColumn, Transformer: ansiCells.Score},
+	})
+	t.SetStyle(table.StyleLight)
+	t.Style().Options.SeparateRows = true
+	t.Render()
+}
@@ -0,0 +1,195 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"spotinfo/pkg/known"
+	"spotinfo/pkg/models"
+	"spotinfo/pkg/spot_analyze/aws"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// column keys accepted by --columns
+const (
+	regionKey          = "region"
+	azKey              = "az"
+	instanceKey        = "instance"
+	vcpuKey            = "vcpu"
+	memoryKey          = "memory"
+	savingsKey         = "savings"
+	interruptionKey    = "interruption"
+	interruptionMinKey = "interruption-min"
+	interruptionMaxKey = "interruption-max"
+	emrKey             = "emr"
+	scoreKey           = "score"
+	priceKey           = "price"
+)
+
+// column one output column, shared by the table and the delimited writers
+type column struct {
+	// Key --columns name, the score columns of every lifetime share it
+	Key    string
+	Header string
+	// Value the cell of advice in az, az is empty in normal mode. nil is a missing value
+	Value func(advice *models.Advice, az string) interface{}
+}
+
+// columnDef a selectable column, score columns are built per lifetime
+type columnDef struct {
+	Header string
+	Value  func(advice *models.Advice, az string) interface{}
+	// ScoreOnly only meaningful in score mode
+	ScoreOnly bool
+}
+
+var columnDefs = map[string]columnDef{
+	regionKey:          {Header: regionColumn, Value: func(a *models.Advice, _ string) interface{} { return a.Region }},
+	azKey:              {Header: azColumn, Value: azValue, ScoreOnly: true},
+	instanceKey:        {Header: instanceTypeColumn, Value: func(a *models.Advice, _ string) interface{} { return a.Instance }},
+	vcpuKey:            {Header: vCPUColumn, Value: func(a *models.Advice, _ string) interface{} { return a.Info.Cores }},
+	memoryKey:          {Header: memoryColumn, Value: func(a *models.Advice, _ string) interface{} { return a.Info.RAM }},
+	savingsKey:         {Header: savingsColumn, Value: func(a *models.Advice, _ string) interface{} { return a.Savings }},
+	interruptionKey:    {Header: interruptionColumn, Value: func(a *models.Advice, _ string) interface{} { return a.Range.Label }},
+	interruptionMinKey: {Header: interruptionMinCol, Value: func(a *models.Advice, _ string) interface{} { return a.Range.Min }},
+	interruptionMaxKey: {Header: interruptionMaxCol, Value: func(a *models.Advice, _ string) interface{} { return a.Range.Max }},
+	emrKey:             {Header: emrColumn, Value: func(a *models.Advice, _ string) interface{} { return a.Info.Emr }},
+	scoreKey:           {Header: scoreColumn, ScoreOnly: true},
+	priceKey:           {Header: priceColumn, Value: func(a *models.Advice, _ string) interface{} { return a.Price }},
+}
+
+// azValue the AZ of a score mode row, missing for the row of an advice without AZ
+func azValue(_ *models.Advice, az string) interface{} {
+	if az == "" {
+		return nil
+	}
+	return az
+}
+
+// columnKeys all --columns names, in the default order
+var columnKeys = []string{regionKey, azKey, instanceKey, vcpuKey, memoryKey, savingsKey, interruptionKey,
+	interruptionMinKey, interruptionMaxKey, emrKey, scoreKey, priceKey}
+
+// validateColumns check the --columns names, score mode columns can't be selected in normal mode
+func validateColumns(keys []string, mode string) error {
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		def, ok := columnDefs[key]
+		if !ok {
+			return errors.Errorf("invalid column %q, must be one of %s", key, strings.Join(columnKeys, ","))
+		}
+		if def.ScoreOnly && mode != known.ScoreMode {
+			return errors.Errorf("column %q is only available in %s mode", key, known.ScoreMode)
+		}
+		if seen[key] {
+			return errors.Errorf("column %q selected twice", key)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// defaultColumns the columns printed without --columns
+func defaultColumns(r *report) []string {
+	var keys []string
+	if r.PrintRegion {
+		keys = append(keys, regionKey)
+	}
+	if r.Opts.Mode == known.ScoreMode {
+		keys = append(keys, azKey)
+	}
+	keys = append(keys, instanceKey, vcpuKey, memoryKey, savingsKey, interruptionKey)
+	if r.Opts.Mode == known.ScoreMode {
+		keys = append(keys, scoreKey)
+	}
+	return append(keys, priceKey)
+}
+
+// scoreColumns one score column per lifetime, named after the lifetime when there are several
+func scoreColumns(lifetimes []int) []string {
+	if len(lifetimes) == 1 {
+		return []string{scoreColumn}
+	}
+	columns := make([]string, 0, len(lifetimes))
+	for _, lifetime := range lifetimes {
+		columns = append(columns, fmt.Sprintf("%s %dh", scoreColumn, lifetime))
+	}
+	return columns
+}
+
+// reportColumns the columns printed for r, the --columns selection or the mode defaults
+func reportColumns(r *report) []column {
+	keys := r.Opts.Columns
+	if len(keys) == 0 {
+		keys = defaultColumns(r)
+	}
+	var columns []column
+	for _, key := range keys {
+		def, ok := columnDefs[key]
+		if !ok || (def.ScoreOnly && r.Opts.Mode != known.ScoreMode) {
+			continue
+		}
+		if key != scoreKey {
+			columns = append(columns, column{Key: key, Header: def.Header, Value: def.Value})
+			continue
+		}
+		for i, name := range scoreColumns(r.Lifetimes) {
+			lifetime := r.Lifetimes[i]
+			columns = append(columns, column{Key: key, Header: name, Value: func(a *models.Advice, az string) interface{} {
+				if score, ok := a.Score[az][lifetime]; ok {
+					return score
+				}
+				return nil
+			}})
+		}
+	}
+	return columns
+}
+
+// reportRows the cells of r, score mode has one row per region, instance and AZ of the region,
+// scored or not. Advices without any AZ get a single row. Rows are sorted by the --sort keys,
+// AZ rows by the values of their AZ
+func reportRows(r *report, columns []column) [][]interface{} {
+	type entry struct {
+		advice *models.Advice
+		az     string
+	}
+	var entries []entry
+	for i := range r.Advices {
+		advice := &r.Advices[i]
+		if r.Opts.Mode != known.ScoreMode {
+			entries = append(entries, entry{advice: advice})
+			continue
+		}
+		azs := r.Zones[advice.Region]
+		if len(azs) == 0 {
+			azs = make([]string, 0, len(advice.Score))
+			for az := range advice.Score {
+				azs = append(azs, az)
+			}
+			sort.Strings(azs)
+		}
+		if len(azs) == 0 {
+			entries = append(entries, entry{advice: advice})
+			continue
+		}
+		for _, az := range azs {
+			entries = append(entries, entry{advice: advice, az: az})
+		}
+	}
+	// validated up front, invalid keys fall back to the advice order
+	keys, _ := aws.ParseSort(r.Opts.Sort, r.Opts.Order)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return aws.CompareAdvices(keys, entries[i].advice, entries[i].az, entries[j].advice, entries[j].az) < 0
+	})
+	rows := make([][]interface{}, 0, len(entries))
+	for _, e := range entries {
+		cells := make([]interface{}, len(columns))
+		for i, c := range columns {
+			cells[i] = c.Value(e.advice, e.az)
+		}
+		rows = append(rows, cells)
+	}
+	return rows
+}
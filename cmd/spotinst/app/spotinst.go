@@ -1,18 +1,21 @@
 package app
 
 import (
+	"bytes"
 	"context"
 	"flag"
 	"fmt"
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/jedib0t/go-pretty/v6/text"
-	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"io"
 	"os"
 	"spotinfo/pkg/known"
-	"spotinfo/pkg/models"
 	"spotinfo/pkg/options"
 	"spotinfo/pkg/spot_analyze/aws"
+	"strings"
+
+	"github.com/pkg/errors"
 )
 
 const (
@@ -25,6 +28,9 @@ const (
 	interruptionColumn = "Frequency of interruption"
 	scoreColumn        = "Spot Market Score"
 	priceColumn        = "USD/Hour"
+	emrColumn          = "EMR"
+	interruptionMinCol = "Interruption Min %"
+	interruptionMaxCol = "Interruption Max %"
 )
 
 func NewSpotinstCommand(ctx context.Context) *cobra.Command {
@@ -35,8 +41,22 @@ func NewSpotinstCommand(ctx context.Context) *cobra.Command {
 		DisableFlagsInUseLine: true,
 		SilenceUsage:          true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if _, ok := os.LookupEnv("SpotinstAccessToken"); !ok {
-				return errors.New("env: SpotinstAccessToken not exist")
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			if _, err := getRenderer(opts.Output); err != nil {
+				return err
+			}
+			if err := validateColumns(opts.Columns, opts.Mode); err != nil {
+				return err
+			}
+			if _, err := aws.ParseSort(opts.Sort, opts.Order); err != nil {
+				return err
+			}
+			if opts.Mode == known.ScoreMode && opts.Source == known.HTTPSource {
+				if _, err := aws.NewTokenProvider(opts, nil, nil); err != nil {
+					return err
+				}
 			}
 			if err := Run(ctx, opts); err != nil {
 				return err
@@ -46,125 +66,158 @@ func NewSpotinstCommand(ctx context.Context) *cobra.Command {
 	}
 	cmd.Flags().AddGoFlagSet(flag.CommandLine)
 	opts.AddFlags(cmd.Flags())
+	opts.AddCacheFlags(cmd.PersistentFlags())
+	opts.AddSpotinstFlags(cmd.PersistentFlags())
+	cmd.AddCommand(NewCacheCommand(ctx, opts))
+	cmd.AddCommand(NewAccountsCommand(ctx, opts))
+	cmd.AddCommand(NewRecommendCommand(ctx, opts))
+	cmd.AddCommand(NewFleetCommand(ctx, opts))
 	return cmd
 }
 
 func Run(ctx context.Context, opts *options.SpotinstOptions) error {
-	advices, err := aws.GetSpotSavings(ctx, opts)
+	render, err := getRenderer(opts.Output)
 	if err != nil {
 		return err
 	}
-	printRegion := len(opts.Region) > 1 || (len(opts.Region) == 1 && opts.Region[0] == "all")
-	printAdvicesTable(advices, printRegion, opts.Mode)
-	return nil
+	r, err := loadReport(ctx, opts)
+	if err != nil {
+		return err
+	}
+	if opts.OutputFile == "" {
+		return render(os.Stdout, r)
+	}
+	// render first, a failing renderer doesn't leave a truncated file behind
+	var buf bytes.Buffer
+	if err := render(&buf, r); err != nil {
+		return err
+	}
+	return errors.Wrapf(os.WriteFile(opts.OutputFile, buf.Bytes(), 0o644), "failed to write %s", opts.OutputFile)
 }
 
-func printAdvicesTable(advices []models.Advice, region bool, mode string) {
-	t := table.NewWriter()
-	t.SetOutputMirror(os.Stdout)
-	var header table.Row
-	var tableColumnConfigs []table.ColumnConfig
-	switch mode {
-	case known.ScoreMode:
-		header = table.Row{azColumn, instanceTypeColumn, vCPUColumn, memoryColumn, savingsColumn, interruptionColumn, scoreColumn, priceColumn}
-	default:
-		header = table.Row{instanceTypeColumn, vCPUColumn, memoryColumn, savingsColumn, interruptionColumn, priceColumn}
+// loadReport the filtered advices of opts
+func loadReport(ctx context.Context, opts *options.SpotinstOptions) (*report, error) {
+	src, err := aws.NewSource(opts)
+	if err != nil {
+		return nil, err
+	}
+	analyzer := aws.NewAnalyzer(src)
+	advices, err := analyzer.GetSpotSavings(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	printRegion := len(opts.Region) > 1 || (len(opts.Region) == 1 && opts.Region[0] == "all")
+	lifetimes := opts.Lifetimes
+	if len(lifetimes) == 0 {
+		lifetimes = aws.DefaultLifetimes
+	}
+	return &report{
+		Advices:     advices,
+		Opts:        opts,
+		Lifetimes:   lifetimes,
+		PrintRegion: printRegion,
+		Warnings:    analyzer.Warnings(),
+		Feeds:       analyzer.FeedStatus(),
+		Zones:       analyzer.Zones(),
+	}, nil
+}
 
+// scoreLevel rate a market score: high, medium, low, poor, or none for unknown and zero scores
+func scoreLevel(score int) string {
+	switch {
+	case score > 75:
+		return "high"
+	case score > 50:
+		return "medium"
+	case score > 25:
+		return "low"
+	case score > 0:
+		return "poor"
 	}
-	if region {
-		header = append(table.Row{regionColumn}, header...)
+	return "none"
+}
+
+var scoreColors = map[string]text.Color{
+	"high":   text.FgHiGreen,
+	"medium": text.FgHiYellow,
+	"low":    text.FgHiMagenta,
+	"poor":   text.FgHiRed,
+	"none":   text.FgWhite,
+}
+
+// scoreTransformer color a market score by how good it is
+func scoreTransformer(val interface{}) string {
+	score, ok := val.(int)
+	if !ok {
+		return fmt.Sprint(val)
 	}
-	t.AppendHeader(header)
-	for _, advice := range advices {
-		row := table.Row{}
-		tableColumnConfigs = append(tableColumnConfigs, table.ColumnConfig{
-			Name:        instanceTypeColumn,
-			Number:      3,
-			AutoMerge:   true,
-			Align:       text.AlignLeft,
-			AlignHeader: text.AlignCenter,
-			AlignFooter: text.AlignCenter,
-		})
-		switch mode {
-		case known.ScoreMode:
-			tableColumnConfigs = append(tableColumnConfigs, table.ColumnConfig{
-				Name:        "REGION",
-				Number:      1,
-				AutoMerge:   true,
-				Align:       text.AlignLeft,
-				AlignHeader: text.AlignCenter,
-				AlignFooter: text.AlignCenter,
-			})
-			tableColumnConfigs = append(tableColumnConfigs, table.ColumnConfig{
-				Name:   scoreColumn,
-				Number: 8,
-				Transformer: func(val interface{}) string {
-					var color text.Color
-					fmt.Println("score", val)
-					score := val.(int)
+	return text.Colors{scoreColors[scoreLevel(score)]}.Sprint(val)
+}
 
-					if score < 100 && score > 75 {
-						color = text.FgHiGreen
-					} else if score < 75 && score > 50 {
-						color = text.FgHiYellow
-					} else if score < 50 && score > 25 {
-						color = text.FgHiMagenta
-					} else if score < 25 && score > 0 {
-						color = text.FgHiRed
-					} else {
-						color = text.FgWhite
-					}
+// cellStyle how the cells of an advices table are rendered, nil transformers keep the plain value
+type cellStyle struct {
+	Score   text.Transformer
+	Savings text.Transformer
+	Other   text.Transformer
+}
 
-					return text.Colors{color}.Sprint(val)
-				},
-			})
-			for az, score := range advice.Score {
-				row = table.Row{az, advice.Instance, advice.Info.Cores, advice.Info.RAM, advice.Savings,
-					advice.Range.Label, score, advice.Price}
-				if region {
-					row = append(table.Row{advice.Region}, row...)
-				}
-				t.AppendRow(row)
-			}
-		default:
-			row = table.Row{advice.Instance, advice.Info.Cores, advice.Info.RAM, advice.Savings, advice.Range.Label, advice.Price}
-			if region {
-				row = append(table.Row{advice.Region}, row...)
+// ansiCells colored terminal cells
+var ansiCells = cellStyle{
+	Score:   scoreTransformer,
+	Savings: text.NewNumberTransformer("%d%%"),
+}
+
+// newAdvicesTable the advices table of r, warnings are left to the caller
+func newAdvicesTable(r *report, style cellStyle) table.Writer {
+	t := table.NewWriter()
+	columns := reportColumns(r)
+	header := make(table.Row, 0, len(columns))
+	var tableColumnConfigs []table.ColumnConfig
+	for _, c := range columns {
+		header = append(header, c.Header)
+		config := table.ColumnConfig{Name: c.Header, Transformer: style.Other}
+		switch c.Key {
+		case regionKey, instanceKey:
+			config.AutoMerge = true
+			config.Align = text.AlignLeft
+			config.AlignHeader = text.AlignCenter
+			config.AlignFooter = text.AlignCenter
+		case savingsKey:
+			config.Transformer = style.Savings
+		case scoreKey:
+			config.Transformer = style.Score
+		}
+		tableColumnConfigs = append(tableColumnConfigs, config)
+	}
+	t.AppendHeader(header)
+	// rows come sorted
+	for _, cells := range reportRows(r, columns) {
+		row := make(table.Row, len(cells))
+		for i, cell := range cells {
+			if cell == nil {
+				cell = "-"
 			}
-			t.AppendRow(row)
+			row[i] = cell
 		}
+		t.AppendRow(row)
 	}
-	// render as pretty table
-	tableColumnConfigs = append(tableColumnConfigs, table.ColumnConfig{
-		Name:        savingsColumn,
-		Transformer: text.NewNumberTransformer("%d%%"),
-	})
-	t.Style().Title.Align = text.AlignCenter
 	t.SetColumnConfigs(tableColumnConfigs)
+	return t
+}
+
+func printAdvicesTable(w io.Writer, r *report) {
+	t := newAdvicesTable(r, ansiCells)
+	t.SetOutputMirror(w)
+	setWarnings(t, r.Warnings)
+	t.Style().Title.Align = text.AlignCenter
 	t.SetStyle(table.StyleLight)
 	t.Style().Options.SeparateRows = true
-	t.SortBy([]table.SortBy{
-		{
-			Name:   instanceTypeColumn,
-			Number: 3,
-			Mode:   table.Asc,
-		},
-		{
-			Name:   azColumn,
-			Number: 2,
-			Mode:   table.Asc,
-		},
-		{
-			Name:   scoreColumn,
-			Number: 8,
-			Mode:   table.Asc,
-		},
-		{
-			Name:   priceColumn,
-			Number: 9,
-			Mode:   table.Dsc,
-		},
-	})
 	t.Render()
+}
 
+// setWarnings print the warnings under a terminal table, footer cells would widen every column to the warning
+func setWarnings(t table.Writer, warnings []string) {
+	if len(warnings) > 0 {
+		t.SetCaption(strings.Join(warnings, "\n"))
+	}
 }
@@ -0,0 +1,100 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// tfVars variables of the tfvars and hcl-json outputs, the names are part of the output contract
+type tfVars struct {
+	Region string `json:"spot_region"`
+	// InstanceTypes in the --sort order
+	InstanceTypes []string `json:"spot_instance_types"`
+	// InstanceZones scored AZs of every instance type, best first
+	InstanceZones map[string][]string `json:"spot_instance_zones"`
+	// MaxPrice --max-price, or the highest spot price of the instance types when not set
+	MaxPrice float64 `json:"spot_max_price"`
+}
+
+func reportTFVars(r *report) (*tfVars, error) {
+	region, err := singleRegion(r.Advices)
+	if err != nil {
+		return nil, err
+	}
+	vars := &tfVars{Region: region, InstanceTypes: []string{}, InstanceZones: make(map[string][]string), MaxPrice: r.Opts.MaxPrice}
+	for i := range r.Advices {
+		advice := &r.Advices[i]
+		vars.InstanceTypes = append(vars.InstanceTypes, advice.Instance)
+		if r.Opts.MaxPrice == 0 && advice.Price > vars.MaxPrice {
+			vars.MaxPrice = advice.Price
+		}
+		zones := make([]string, 0, len(advice.Score))
+		for az, score := range advice.Score {
+			if len(score) > 0 {
+				zones = append(zones, az)
+			}
+		}
+		if len(zones) == 0 {
+			continue
+		}
+		sort.Slice(zones, func(i, j int) bool {
+			si, sj := advice.Score[zones[i]].Min(), advice.Score[zones[j]].Min()
+			if si != sj {
+				return si > sj
+			}
+			return zones[i] < zones[j]
+		})
+		vars.InstanceZones[advice.Instance] = zones
+	}
+	if len(vars.InstanceTypes) == 0 {
+		return nil, errors.New("no instance type matches the filters, the tfvars would be empty")
+	}
+	return vars, nil
+}
+
+// hclList a list of quoted strings, strconv.Quote escapes like HCL for the names we print
+func hclList(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		quoted = append(quoted, strconv.Quote(v))
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+func renderTFVars(w io.Writer, r *report) error {
+	vars, err := reportTFVars(r)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, "# generated by spotinfo, changes are overwritten")
+	fmt.Fprintf(&buf, "spot_region = %s\n", strconv.Quote(vars.Region))
+	fmt.Fprintf(&buf, "spot_instance_types = %s\n", hclList(vars.InstanceTypes))
+	instances := make([]string, 0, len(vars.InstanceZones))
+	for instance := range vars.InstanceZones {
+		instances = append(instances, instance)
+	}
+	sort.Strings(instances)
+	fmt.Fprintln(&buf, "spot_instance_zones = {")
+	for _, instance := range instances {
+		fmt.Fprintf(&buf, "  %s = %s\n", strconv.Quote(instance), hclList(vars.InstanceZones[instance]))
+	}
+	fmt.Fprintln(&buf, "}")
+	fmt.Fprintf(&buf, "spot_max_price = %s\n", strconv.FormatFloat(vars.MaxPrice, 'f', -1, 64))
+	_, err = w.Write(buf.Bytes())
+	return errors.Wrap(err, "failed to write tfvars")
+}
+
+func renderHCLJSON(w io.Writer, r *report) error {
+	vars, err := reportTFVars(r)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, vars)
+}
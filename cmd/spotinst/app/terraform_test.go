@@ -0,0 +1,53 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRenderTFVars(t *testing.T) {
+	var buf bytes.Buffer
+	if err := renderTFVars(&buf, testReport()); err != nil {
+		t.Fatal(err)
+	}
+	want := `# generated by spotinfo, changes are overwritten
+spot_region = "us-east-1"
+spot_instance_types = ["m5.large"]
+spot_instance_zones = {
+  "m5.large" = ["us-east-1a", "us-east-1b"]
+}
+spot_max_price = 0.04
+`
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+	r := testReport()
+	r.Advices = nil
+	if err := renderTFVars(&buf, r); err == nil {
+		t.Error("expected a no instance type error")
+	}
+}
+
+func TestRenderHCLJSON(t *testing.T) {
+	r := testReport()
+	r.Opts.MaxPrice = 0.1
+	var buf bytes.Buffer
+	if err := renderHCLJSON(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	var vars tfVars
+	if err := json.Unmarshal(buf.Bytes(), &vars); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, buf.String())
+	}
+	want := tfVars{
+		Region:        "us-east-1",
+		InstanceTypes: []string{"m5.large"},
+		InstanceZones: map[string][]string{"m5.large": {"us-east-1a", "us-east-1b"}},
+		MaxPrice:      0.1,
+	}
+	if !reflect.DeepEqual(vars, want) {
+		t.Errorf("got %+v, want %+v", vars, want)
+	}
+}
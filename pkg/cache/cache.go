@@ -0,0 +1,219 @@
+package cache
+
+import (
+	"encoding/json"
+	"github.com/bytedance/sonic"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SchemaVersion version of the cache envelope, entries written with another version are ignored
+const SchemaVersion = 1
+
+// cached feeds
+const (
+	AdvisorFeed = "advisor"
+	PriceFeed   = "price"
+	ScoreFeed   = "score"
+	ZonesFeed   = "zones"
+	// TokenFeed the Spotinst access tokens, not a data feed, one entry per endpoint and user
+	TokenFeed = "token"
+)
+
+// Feeds all cached feeds
+var Feeds = []string{AdvisorFeed, PriceFeed, ScoreFeed, ZonesFeed}
+
+const fileSuffix = ".json"
+
+// ErrMiss returned by Load when there is no usable entry for a key
+var ErrMiss = errors.New("cache miss")
+
+// Entry cache envelope stored on disk
+type Entry struct {
+	Version   int             `json:"version"`
+	Feed      string          `json:"feed"`
+	Key       string          `json:"key"`
+	FetchedAt time.Time       `json:"fetched_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Age time elapsed since the entry was fetched
+func (e *Entry) Age() time.Duration {
+	return time.Since(e.FetchedAt)
+}
+
+// Decode unmarshal the entry data into v
+func (e *Entry) Decode(v interface{}) error {
+	return errors.Wrapf(sonic.Unmarshal(e.Data, v), "failed to decode cache entry %s", e.Key)
+}
+
+// Cache file based cache, one JSON envelope per key
+type Cache struct {
+	Dir string
+	// TTL time to live per feed, feeds without TTL never expire
+	TTL map[string]time.Duration
+}
+
+// New create a cache stored in dir
+func New(dir string, ttl map[string]time.Duration) *Cache {
+	if dir == "" {
+		dir = DefaultDir()
+	}
+	return &Cache{Dir: dir, TTL: ttl}
+}
+
+// DefaultDir $XDG_CACHE_HOME/spotinfo, falling back to the OS user cache directory
+func DefaultDir() string {
+	base := os.Getenv("XDG_CACHE_HOME")
+	if base == "" {
+		var err error
+		if base, err = os.UserCacheDir(); err != nil {
+			base = os.TempDir()
+		}
+	}
+	return filepath.Join(base, "spotinfo")
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.Dir, key+fileSuffix)
+}
+
+// Expired report whether the entry outlived the TTL of its feed
+func (c *Cache) Expired(e *Entry) bool {
+	ttl, ok := c.TTL[e.Feed]
+	return ok && e.Age() > ttl
+}
+
+// Load read the entry stored for key, ErrMiss is returned for missing or incompatible entries
+func (c *Cache) Load(key string) (*Entry, error) {
+	content, err := os.ReadFile(c.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrMiss
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read cache entry")
+	}
+	var e Entry
+	if err = sonic.Unmarshal(content, &e); err != nil || e.Version != SchemaVersion {
+		return nil, ErrMiss
+	}
+	return &e, nil
+}
+
+// Store marshal v and write it under key, the write is atomic: readers see
+// either the previous entry or the new one
+func (c *Cache) Store(feed, key string, v interface{}) (*Entry, error) {
+	data, err := sonic.Marshal(v)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode cache entry")
+	}
+	e := &Entry{
+		Version:   SchemaVersion,
+		Feed:      feed,
+		Key:       key,
+		FetchedAt: time.Now().UTC(),
+		Data:      data,
+	}
+	content, err := sonic.Marshal(e)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode cache entry")
+	}
+	if err = os.MkdirAll(c.Dir, 0o755); err != nil {
+		return nil, errors.Wrap(err, "failed to create cache dir")
+	}
+	f, err := os.CreateTemp(c.Dir, key+".*.tmp")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create cache entry")
+	}
+	defer os.Remove(f.Name()) // no-op once renamed
+	if _, err = f.Write(content); err != nil {
+		f.Close()
+		return nil, errors.Wrap(err, "failed to write cache entry")
+	}
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return nil, errors.Wrap(err, "failed to write cache entry")
+	}
+	if err = f.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed to write cache entry")
+	}
+	if err = os.Rename(f.Name(), c.path(key)); err != nil {
+		return nil, errors.Wrap(err, "failed to write cache entry")
+	}
+	return e, nil
+}
+
+// List return the usable entries sorted by key
+func (c *Cache) List() ([]*Entry, error) {
+	files, err := os.ReadDir(c.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list cache dir")
+	}
+	var entries []*Entry
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), fileSuffix) {
+			continue
+		}
+		e, err := c.Load(strings.TrimSuffix(f.Name(), fileSuffix))
+		if err == ErrMiss {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries, nil
+}
+
+// Remove delete the entry stored for key, a missing entry is not an error
+func (c *Cache) Remove(key string) error {
+	if err := os.Remove(c.path(key)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "failed to remove cache entry")
+	}
+	return nil
+}
+
+// Clear remove the entries of the given feeds, every entry when no feed is given
+func (c *Cache) Clear(feeds ...string) error {
+	files, err := os.ReadDir(c.Dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to list cache dir")
+	}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), fileSuffix) {
+			continue
+		}
+		if len(feeds) > 0 {
+			e, err := c.Load(strings.TrimSuffix(f.Name(), fileSuffix))
+			if err != nil || !contains(feeds, e.Feed) {
+				continue
+			}
+		}
+		if err = os.Remove(filepath.Join(c.Dir, f.Name())); err != nil {
+			return errors.Wrap(err, "failed to remove cache entry")
+		}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type payload struct {
+	Name  string `json:"name"`
+	Value int    `json:"value"`
+}
+
+func TestStoreLoad(t *testing.T) {
+	c := New(t.TempDir(), map[string]time.Duration{AdvisorFeed: time.Hour})
+	if _, err := c.Load(AdvisorFeed); err != ErrMiss {
+		t.Fatalf("empty cache: got %v, want ErrMiss", err)
+	}
+	if _, err := c.Store(AdvisorFeed, AdvisorFeed, payload{Name: "a", Value: 1}); err != nil {
+		t.Fatal(err)
+	}
+	e, err := c.Load(AdvisorFeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got payload
+	if err = e.Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got != (payload{Name: "a", Value: 1}) {
+		t.Errorf("got %+v", got)
+	}
+	if e.Version != SchemaVersion || e.Feed != AdvisorFeed {
+		t.Errorf("unexpected envelope %+v", e)
+	}
+	if c.Expired(e) {
+		t.Error("fresh entry reported as expired")
+	}
+	e.FetchedAt = time.Now().Add(-2 * time.Hour)
+	if !c.Expired(e) {
+		t.Error("old entry not reported as expired")
+	}
+	// no leftover temp files
+	files, _ := os.ReadDir(c.Dir)
+	if len(files) != 1 {
+		t.Errorf("got %d files in cache dir, want 1", len(files))
+	}
+}
+
+func TestLoadIncompatible(t *testing.T) {
+	c := New(t.TempDir(), nil)
+	content := `{"version": 0, "feed": "price", "key": "price", "data": {}}`
+	if err := os.WriteFile(filepath.Join(c.Dir, "price.json"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Load(PriceFeed); err != ErrMiss {
+		t.Errorf("got %v, want ErrMiss", err)
+	}
+}
+
+func TestListClear(t *testing.T) {
+	c := New(t.TempDir(), nil)
+	for _, feed := range Feeds {
+		if _, err := c.Store(feed, feed, payload{Name: feed}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := c.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(Feeds) || entries[0].Key != AdvisorFeed {
+		t.Fatalf("unexpected entries %v", entries)
+	}
+	if err = c.Clear(PriceFeed); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.Load(PriceFeed); err != ErrMiss {
+		t.Errorf("price not cleared: %v", err)
+	}
+	if _, err = c.Load(ScoreFeed); err != nil {
+		t.Errorf("score cleared: %v", err)
+	}
+	if err = c.Remove(ZonesFeed); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.Load(ZonesFeed); err != ErrMiss {
+		t.Errorf("zones not removed: %v", err)
+	}
+	if err = c.Remove(ZonesFeed); err != nil {
+		t.Errorf("removing a missing entry: %v", err)
+	}
+	if err = c.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ = c.List(); len(entries) != 0 {
+		t.Errorf("got %d entries after clear", len(entries))
+	}
+}
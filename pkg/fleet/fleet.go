@@ -0,0 +1,184 @@
+package fleet
+
+import (
+	"math"
+	"sort"
+	"spotinfo/pkg/models"
+	"spotinfo/pkg/recommend"
+)
+
+// capacity units
+const (
+	VCPUUnit   = "vcpu"
+	MemoryUnit = "memory"
+)
+
+// Spec the capacity to reach and how much to diversify
+type Spec struct {
+	// Unit VCPUUnit or MemoryUnit, Target the capacity in vCPUs or memory GiB
+	Unit   string
+	Target float64
+	// MaxTypes instance types per fleet, MaxPerFamily instance types of the same family, e.g. m
+	MaxTypes     int
+	MaxPerFamily int
+	// MaxZones AZs every instance type is spread over, picked by score
+	MaxZones int
+	// Arch processor architecture of the instance types, one launch template boots a single one.
+	// Empty for the architecture of the best ranked candidate of every region
+	Arch    string
+	Weights recommend.Weights
+}
+
+// Member an instance type of a fleet
+type Member struct {
+	Advice models.Advice `json:"advice"`
+	// Weight capacity units of one instance
+	Weight float64 `json:"weight"`
+	Count  int     `json:"count"`
+	// Capacity Weight * Count
+	Capacity   float64 `json:"capacity"`
+	HourlyCost float64 `json:"hourly_cost"`
+	// Zones instances per AZ, empty when the AZs have no score
+	Zones     map[string]int `json:"zones,omitempty"`
+	Composite float64        `json:"composite"`
+}
+
+// Fleet diversified instance types of a region
+type Fleet struct {
+	Region string  `json:"region"`
+	Unit   string  `json:"unit"`
+	Target float64 `json:"target"`
+	// Arch processor architecture of the members, empty when none is known
+	Arch       string  `json:"arch"`
+	Capacity   float64 `json:"capacity"`
+	HourlyCost float64 `json:"hourly_cost"`
+	// Interruption band of the capacity weighted frequency of interruption
+	Interruption models.InterruptionRange `json:"interruption"`
+	Members      []Member                 `json:"members"`
+}
+
+// Compose pick a fleet per region out of advices. Candidates are taken in recommend.Rank order, skipping
+// the ones of a family already picked MaxPerFamily times, and the target capacity is split evenly
+// between them. Instance types without a price are left out, their cost is unknown.
+// Members share the architecture of spec.Arch, or of the best ranked candidate without it
+func Compose(advices []models.Advice, spec Spec) []Fleet {
+	regions := make(map[string][]models.Advice)
+	var names []string
+	for _, advice := range advices {
+		if advice.Price <= 0 || weight(&advice, spec.Unit) <= 0 {
+			continue
+		}
+		if spec.Arch != "" && advice.Type.Arch != spec.Arch {
+			continue
+		}
+		if _, ok := regions[advice.Region]; !ok {
+			names = append(names, advice.Region)
+		}
+		regions[advice.Region] = append(regions[advice.Region], advice)
+	}
+	sort.Strings(names)
+	var result []Fleet
+	for _, region := range names {
+		result = append(result, compose(region, regions[region], spec))
+	}
+	return result
+}
+
+func compose(region string, advices []models.Advice, spec Spec) Fleet {
+	f := Fleet{Region: region, Unit: spec.Unit, Target: spec.Target, Arch: spec.Arch}
+	families := make(map[string]int)
+	for _, rec := range recommend.Rank(advices, spec.Weights, 0) {
+		if spec.MaxTypes > 0 && len(f.Members) == spec.MaxTypes {
+			break
+		}
+		// the first known architecture in rank order wins, unknown ones may run either
+		arch := rec.Advice.Type.Arch
+		if f.Arch == "" {
+			f.Arch = arch
+		}
+		if arch != "" && arch != f.Arch {
+			continue
+		}
+		family := rec.Advice.Type.Family
+		if family == "" {
+			family = rec.Advice.Instance
+		}
+		if spec.MaxPerFamily > 0 && families[family] == spec.MaxPerFamily {
+			continue
+		}
+		families[family]++
+		f.Members = append(f.Members, Member{Advice: rec.Advice, Weight: weight(&rec.Advice, spec.Unit), Composite: rec.Composite})
+	}
+	if len(f.Members) == 0 {
+		return f
+	}
+	share := spec.Target / float64(len(f.Members))
+	interruption := 0.0
+	for i := range f.Members {
+		m := &f.Members[i]
+		m.Count = int(math.Ceil(share / m.Weight))
+		if m.Count < 1 {
+			m.Count = 1
+		}
+		m.Capacity = m.Weight * float64(m.Count)
+		m.HourlyCost = m.Advice.Price * float64(m.Count)
+		m.Zones = spread(&m.Advice, m.Count, spec.MaxZones)
+		f.Capacity += m.Capacity
+		f.HourlyCost += m.HourlyCost
+		interruption += m.Capacity * float64(m.Advice.Range.Max)
+	}
+	f.Interruption = band(advices, interruption/f.Capacity)
+	return f
+}
+
+// weight capacity units of one instance of advice
+func weight(advice *models.Advice, unit string) float64 {
+	if unit == MemoryUnit {
+		return float64(advice.Info.RAM)
+	}
+	return float64(advice.Info.Cores)
+}
+
+// spread count instances over the maxZones best scored AZs, round robin
+func spread(advice *models.Advice, count, maxZones int) map[string]int {
+	zones := make([]string, 0, len(advice.Score))
+	for az, s := range advice.Score {
+		if len(s) > 0 {
+			zones = append(zones, az)
+		}
+	}
+	if len(zones) == 0 {
+		return nil
+	}
+	sort.Slice(zones, func(i, j int) bool {
+		si, sj := advice.Score[zones[i]].Min(), advice.Score[zones[j]].Min()
+		if si != sj {
+			return si > sj
+		}
+		return zones[i] < zones[j]
+	})
+	if maxZones > 0 && len(zones) > maxZones {
+		zones = zones[:maxZones]
+	}
+	result := make(map[string]int, len(zones))
+	for i := 0; i < count; i++ {
+		result[zones[i%len(zones)]]++
+	}
+	return result
+}
+
+// band the narrowest interruption band of advices holding max, the widest one when none does
+func band(advices []models.Advice, max float64) models.InterruptionRange {
+	var result models.InterruptionRange
+	found := false
+	for _, advice := range advices {
+		rng := advice.Range
+		if float64(rng.Max) >= max && (!found || rng.Max < result.Max) {
+			result, found = rng, true
+		}
+		if !found && rng.Max > result.Max {
+			result = rng
+		}
+	}
+	return result
+}
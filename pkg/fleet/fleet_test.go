@@ -0,0 +1,95 @@
+package fleet
+
+import (
+	"reflect"
+	"sort"
+	"spotinfo/pkg/instancetype"
+	"spotinfo/pkg/models"
+	"spotinfo/pkg/recommend"
+	"testing"
+)
+
+func testAdvice(instance string, cores int, price float64, rng models.InterruptionRange, score map[string]models.LifetimeScores) models.Advice {
+	info, _ := instancetype.Parse(instance)
+	return models.Advice{Region: "us-east-1", Instance: instance, Savings: 70, Range: rng, Price: price,
+		Info: models.TypeInfo{Cores: cores, RAM: float32(cores * 4)}, Type: info, Score: score}
+}
+
+func TestCompose(t *testing.T) {
+	low := models.InterruptionRange{Label: "<5%", Max: 5}
+	high := models.InterruptionRange{Label: "15-20%", Min: 16, Max: 22}
+	scores := map[string]models.LifetimeScores{"us-east-1a": {1: 90}, "us-east-1b": {1: 70}, "us-east-1c": {1: 50}}
+	advices := []models.Advice{
+		testAdvice("m5.large", 2, 0.04, low, scores),
+		testAdvice("m5.xlarge", 4, 0.08, low, scores),
+		testAdvice("m6i.large", 2, 0.04, low, scores),
+		testAdvice("c5.xlarge", 4, 0.07, high, scores),
+		// no price, left out
+		testAdvice("r5.large", 2, 0, low, scores),
+	}
+	fleets := Compose(advices, Spec{Unit: VCPUUnit, Target: 12, MaxTypes: 3, MaxPerFamily: 1, MaxZones: 2,
+		Weights: recommend.DefaultWeights})
+	if len(fleets) != 1 {
+		t.Fatalf("got %d fleets, want 1", len(fleets))
+	}
+	f := fleets[0]
+	var types []string
+	for _, m := range f.Members {
+		types = append(types, m.Advice.Instance)
+	}
+	// a single m type, c fills the second slot
+	if len(types) != 2 || types[1] != "c5.xlarge" {
+		t.Fatalf("unexpected types %v", types)
+	}
+	m := f.Members[0]
+	if m.Weight != 2 || m.Count != 3 || m.Capacity != 6 {
+		t.Errorf("unexpected member %+v", m)
+	}
+	if !reflect.DeepEqual(m.Zones, map[string]int{"us-east-1a": 2, "us-east-1b": 1}) {
+		t.Errorf("unexpected zones %v", m.Zones)
+	}
+	if f.Capacity != 14 || f.HourlyCost != 0.04*3+0.07*2 {
+		t.Errorf("unexpected totals %g %g", f.Capacity, f.HourlyCost)
+	}
+	// (6*5+8*22)/14 = 14.7 falls in the 15-20% band
+	if f.Interruption.Label != "15-20%" {
+		t.Errorf("unexpected interruption %+v", f.Interruption)
+	}
+}
+
+func TestComposeArch(t *testing.T) {
+	low := models.InterruptionRange{Label: "<5%", Max: 5}
+	high := models.InterruptionRange{Label: ">20%", Min: 23, Max: 100}
+	advices := []models.Advice{
+		testAdvice("m5.large", 2, 0.04, high, nil),
+		testAdvice("m6g.xlarge", 4, 0.06, low, nil),
+		testAdvice("c6g.large", 2, 0.03, low, nil),
+	}
+	tests := []struct {
+		name  string
+		arch  string
+		want  string
+		types []string
+	}{
+		// the best ranked candidate, m6g.xlarge, picks arm64
+		{name: "best ranked", want: instancetype.ARM64, types: []string{"c6g.large", "m6g.xlarge"}},
+		{name: "--arch", arch: instancetype.X8664, want: instancetype.X8664, types: []string{"m5.large"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fleets := Compose(advices, Spec{Unit: VCPUUnit, Target: 8, MaxTypes: 3, Arch: tt.arch,
+				Weights: recommend.DefaultWeights})
+			if len(fleets) != 1 {
+				t.Fatalf("got %d fleets, want 1", len(fleets))
+			}
+			var types []string
+			for _, m := range fleets[0].Members {
+				types = append(types, m.Advice.Instance)
+			}
+			sort.Strings(types)
+			if fleets[0].Arch != tt.want || !reflect.DeepEqual(types, tt.types) {
+				t.Errorf("got %s %v, want %s %v", fleets[0].Arch, types, tt.want, tt.types)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"github.com/cloudwego/hertz/pkg/app/client"
+	"github.com/cloudwego/hertz/pkg/protocol"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Config retry and rate limit settings
+type Config struct {
+	// Retries attempts after the first one on network errors, 429 and 5xx
+	Retries int
+	// Backoff base delay, doubled on every retry and jittered
+	Backoff time.Duration
+	// MaxBackoff cap of the delay between two attempts, Retry-After included
+	MaxBackoff time.Duration
+	// RateLimit requests per second, 0 disables the limiter
+	RateLimit float64
+}
+
+// DefaultConfig used by Default
+var DefaultConfig = Config{
+	Retries:    3,
+	Backoff:    500 * time.Millisecond,
+	MaxBackoff: 30 * time.Second,
+}
+
+var (
+	defaultOnce   sync.Once
+	defaultClient *Client
+)
+
+// Default shared client with DefaultConfig
+func Default() *Client {
+	defaultOnce.Do(func() {
+		defaultClient = New(DefaultConfig)
+	})
+	return defaultClient
+}
+
+// Client hertz client retrying throttled and failed requests
+type Client struct {
+	Config
+	hc      *client.Client
+	limiter *Limiter
+}
+
+// New create a client with cfg
+func New(cfg Config) *Client {
+	hc, _ := client.NewClient(client.WithTLSConfig(&tls.Config{
+		InsecureSkipVerify: true,
+	}))
+	c := &Client{Config: cfg, hc: hc}
+	if cfg.RateLimit > 0 {
+		c.limiter = NewLimiter(cfg.RateLimit, int(cfg.RateLimit+0.5))
+	}
+	return c
+}
+
+// Do send req, network errors, 429 and 5xx responses are retried with a
+// jittered exponential backoff honoring Retry-After. The last response is
+// left in resp once retries are exhausted
+func (c *Client) Do(ctx context.Context, req *protocol.Request, resp *protocol.Response, timeout time.Duration) (err error) {
+	for attempt := 0; ; attempt++ {
+		if c.limiter != nil {
+			if err = c.limiter.Wait(ctx); err != nil {
+				return err
+			}
+		}
+		err = c.hc.DoTimeout(ctx, req, resp, timeout)
+		if attempt >= c.Retries || (err == nil && !Retryable(resp.StatusCode())) {
+			return err
+		}
+		delay := c.backoff(attempt)
+		if err == nil {
+			if after, ok := retryAfter(resp.Header.Peek("Retry-After"), time.Now()); ok {
+				delay = after
+			}
+		}
+		if c.MaxBackoff > 0 && delay > c.MaxBackoff {
+			delay = c.MaxBackoff
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Wrap(ctx.Err(), "request canceled while waiting to retry")
+		case <-timer.C:
+		}
+	}
+}
+
+// Retryable 429 and 5xx responses are worth retrying
+func Retryable(status int) bool {
+	return status == consts.StatusTooManyRequests || status >= consts.StatusInternalServerError
+}
+
+// backoff full jitter: a random delay up to Backoff * 2^attempt
+func (c *Client) backoff(attempt int) time.Duration {
+	ceiling := c.Backoff << uint(attempt)
+	if ceiling <= 0 || (c.MaxBackoff > 0 && ceiling > c.MaxBackoff) {
+		ceiling = c.MaxBackoff
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling)))
+}
+
+// retryAfter parse a Retry-After header, delay in seconds or HTTP date
+func retryAfter(value []byte, now time.Time) (time.Duration, bool) {
+	if len(value) == 0 {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(string(value)); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := time.Parse(time.RFC1123, string(value)); err == nil {
+		if d := at.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
@@ -0,0 +1,116 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/protocol"
+)
+
+func get(t *testing.T, c *Client, url string) int {
+	t.Helper()
+	req, resp := protocol.AcquireRequest(), protocol.AcquireResponse()
+	defer func() {
+		protocol.ReleaseRequest(req)
+		protocol.ReleaseResponse(resp)
+	}()
+	req.SetRequestURI(url)
+	if err := c.Do(context.Background(), req, resp, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode()
+}
+
+func TestClientRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		retries   int
+		want      int
+		wantCalls int32
+	}{
+		{name: "success", statuses: []int{200}, retries: 3, want: 200, wantCalls: 1},
+		{name: "throttled then success", statuses: []int{429, 503, 200}, retries: 3, want: 200, wantCalls: 3},
+		{name: "retries exhausted", statuses: []int{500, 502, 503}, retries: 2, want: 503, wantCalls: 3},
+		{name: "client error not retried", statuses: []int{400, 200}, retries: 3, want: 400, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&calls, 1)
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(tt.statuses[n-1])
+			}))
+			defer srv.Close()
+
+			c := New(Config{Retries: tt.retries, Backoff: time.Second, MaxBackoff: time.Second})
+			if got := get(t, c, srv.URL); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("got %d calls, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2023, 8, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{value: "", ok: false},
+		{value: "3", want: 3 * time.Second, ok: true},
+		{value: "Tue, 01 Aug 2023 10:00:05 UTC", want: 5 * time.Second, ok: true},
+		{value: "Tue, 01 Aug 2023 09:00:00 UTC", want: 0, ok: true},
+		{value: "soon", ok: false},
+	}
+	for _, tt := range tests {
+		got, ok := retryAfter([]byte(tt.value), now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%q): got %v %v, want %v %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	c := New(Config{Backoff: 100 * time.Millisecond, MaxBackoff: time.Second})
+	for attempt := 0; attempt < 10; attempt++ {
+		ceiling := 100 * time.Millisecond << uint(attempt)
+		if ceiling > time.Second {
+			ceiling = time.Second
+		}
+		if d := c.backoff(attempt); d < 0 || d >= ceiling {
+			t.Errorf("attempt %d: backoff %v out of [0, %v)", attempt, d, ceiling)
+		}
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	l := NewLimiter(10, 2)
+	now := l.last
+	// the burst is served right away
+	for i := 0; i < 2; i++ {
+		if d := l.reserve(now); d != 0 {
+			t.Fatalf("token %d: got delay %v", i, d)
+		}
+	}
+	if d := l.reserve(now); d != 100*time.Millisecond {
+		t.Errorf("got delay %v, want 100ms", d)
+	}
+	// refilled after a second, capped by the burst
+	l.reserve(now.Add(time.Second))
+	if d := l.reserve(now.Add(time.Second)); d != 0 {
+		t.Errorf("got delay %v after refill", d)
+	}
+	if d := l.reserve(now.Add(time.Second)); d == 0 {
+		t.Error("burst exceeded")
+	}
+}
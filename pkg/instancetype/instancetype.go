@@ -0,0 +1,88 @@
+package instancetype
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// architectures
+const (
+	ARM64 = "arm64"
+	X8664 = "x86_64"
+)
+
+// processor vendors
+const (
+	AWS   = "aws"
+	AMD   = "amd"
+	Intel = "intel"
+	Apple = "apple"
+)
+
+// Info metadata parsed from an EC2 instance type name like m6gd.xlarge
+type Info struct {
+	Name string `json:"name"`
+	// Family series letters, e.g. m
+	Family     string `json:"family"`
+	Generation int    `json:"generation"`
+	// Attributes letters after the generation, e.g. gd
+	Attributes string `json:"attributes"`
+	Size       string `json:"size"`
+	Vendor     string `json:"vendor"`
+	Arch       string `json:"arch"`
+	Burstable  bool   `json:"burstable"`
+	GPU        bool   `json:"gpu"`
+	// NVMe local NVMe instance storage
+	NVMe bool `json:"nvme"`
+}
+
+// Class family, generation and attributes, e.g. m6gd
+func (i Info) Class() string {
+	return i.Family + strconv.Itoa(i.Generation) + i.Attributes
+}
+
+var nameRe = regexp.MustCompile(`^([a-z]+)-?(\d+)([a-z0-9-]*)\.([a-z0-9-]+)$`)
+
+// gpuFamilies accelerated computing families with GPUs
+var gpuFamilies = map[string]bool{"p": true, "g": true}
+
+// storageFamilies storage optimized families, all of them have local NVMe storage
+var storageFamilies = map[string]bool{"i": true, "im": true, "is": true}
+
+// Parse parse an instance type name
+func Parse(name string) (Info, error) {
+	m := nameRe.FindStringSubmatch(strings.ToLower(name))
+	if m == nil {
+		return Info{}, errors.Errorf("unknown instance type name %q", name)
+	}
+	generation, _ := strconv.Atoi(m[2])
+	info := Info{
+		Name:       name,
+		Family:     m[1],
+		Generation: generation,
+		Attributes: m[3],
+		Size:       m[4],
+		Vendor:     Intel,
+		Arch:       X8664,
+		Burstable:  m[1] == "t",
+		GPU:        gpuFamilies[m[1]],
+		NVMe:       storageFamilies[m[1]],
+	}
+	// the processor attribute comes first, e.g. flex in m7i-flex is not a graviton
+	attrs, _, _ := strings.Cut(info.Attributes, "-")
+	switch {
+	case info.Family == "a" || strings.Contains(attrs, "g"):
+		info.Vendor, info.Arch = AWS, ARM64
+	case info.Family == "mac" && info.Generation >= 2:
+		info.Vendor, info.Arch = Apple, ARM64
+	case strings.HasPrefix(attrs, "a"):
+		info.Vendor = AMD
+	}
+	if strings.Contains(attrs, "d") {
+		info.NVMe = true
+	}
+	return info, nil
+}
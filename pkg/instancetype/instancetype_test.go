@@ -0,0 +1,52 @@
+package instancetype
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		want Info
+	}{
+		{name: "m5.large", want: Info{Family: "m", Generation: 5, Size: "large", Vendor: Intel, Arch: X8664}},
+		{name: "m6gd.xlarge", want: Info{Family: "m", Generation: 6, Attributes: "gd", Size: "xlarge", Vendor: AWS, Arch: ARM64, NVMe: true}},
+		{name: "c5ad.2xlarge", want: Info{Family: "c", Generation: 5, Attributes: "ad", Size: "2xlarge", Vendor: AMD, Arch: X8664, NVMe: true}},
+		{name: "t4g.micro", want: Info{Family: "t", Generation: 4, Attributes: "g", Size: "micro", Vendor: AWS, Arch: ARM64, Burstable: true}},
+		{name: "t3a.medium", want: Info{Family: "t", Generation: 3, Attributes: "a", Size: "medium", Vendor: AMD, Arch: X8664, Burstable: true}},
+		{name: "p4d.24xlarge", want: Info{Family: "p", Generation: 4, Attributes: "d", Size: "24xlarge", Vendor: Intel, Arch: X8664, GPU: true, NVMe: true}},
+		{name: "g5g.xlarge", want: Info{Family: "g", Generation: 5, Attributes: "g", Size: "xlarge", Vendor: AWS, Arch: ARM64, GPU: true}},
+		{name: "i3en.large", want: Info{Family: "i", Generation: 3, Attributes: "en", Size: "large", Vendor: Intel, Arch: X8664, NVMe: true}},
+		{name: "is4gen.medium", want: Info{Family: "is", Generation: 4, Attributes: "gen", Size: "medium", Vendor: AWS, Arch: ARM64, NVMe: true}},
+		{name: "x2iedn.xlarge", want: Info{Family: "x", Generation: 2, Attributes: "iedn", Size: "xlarge", Vendor: Intel, Arch: X8664, NVMe: true}},
+		{name: "m7i-flex.large", want: Info{Family: "m", Generation: 7, Attributes: "i-flex", Size: "large", Vendor: Intel, Arch: X8664}},
+		{name: "a1.metal", want: Info{Family: "a", Generation: 1, Size: "metal", Vendor: AWS, Arch: ARM64}},
+		{name: "mac2.metal", want: Info{Family: "mac", Generation: 2, Size: "metal", Vendor: Apple, Arch: ARM64}},
+		{name: "inf2.xlarge", want: Info{Family: "inf", Generation: 2, Size: "xlarge", Vendor: Intel, Arch: X8664}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.want.Name = tt.name
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+	for _, name := range []string{"", "large", "m5", "5.large"} {
+		if _, err := Parse(name); err == nil {
+			t.Errorf("%q: expected an error", name)
+		}
+	}
+}
+
+func TestClass(t *testing.T) {
+	info, err := Parse("m6gd.xlarge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.Class(); got != "m6gd" {
+		t.Errorf("got %q, want m6gd", got)
+	}
+}
@@ -1,10 +1,18 @@
 package known
 
 const (
-	SpotHost           = "https://console.spotinst.com"
-	SpotAccountId      = "act-4321e68e"
-	SpotSignUri        = "/api/auth/signIn"
-	SpotMarketScoreUri = "/api/aws/ec2/market/score"
+	SpotHost                = "https://console.spotinst.com"
+	SpotAccountId           = "act-4321e68e"
+	SpotSignUri             = "/api/auth/signIn"
+	SpotMarketScoreUri      = "/api/aws/ec2/market/score"
+	SpotAccountsUri         = "/api/setup/account"
+	SpotAvailabilityZoneUri = "/api/aws/ec2/availabilityZone"
+)
+
+// env vars overriding the spotinst flag defaults
+const (
+	SpotAccountEnv  = "SpotinstAccount"
+	SpotEndpointEnv = "SpotinstEndpoint"
 )
 
 const (
@@ -12,18 +20,76 @@ const (
 	ScoreMode  = "score"
 )
 
+// instance operating systems accepted by --os
+const (
+	LinuxOS   = "linux"
+	WindowsOS = "windows"
+	RHELOS    = "rhel"
+	SUSEOS    = "suse"
+)
+
+// ScoreProducts EC2 product of each OS, as the Spotinst market score API names them
+var ScoreProducts = map[string]string{
+	LinuxOS:   "Linux/UNIX (Amazon VPC)",
+	WindowsOS: "Windows (Amazon VPC)",
+	RHELOS:    "Red Hat Enterprise Linux (Amazon VPC)",
+	SUSEOS:    "SUSE Linux (Amazon VPC)",
+}
+
+// --score-match values
+const (
+	AnyZone  = "any"
+	AllZones = "all"
+)
+
+// advice output formats
+const (
+	TableOutput    = "table"
+	JSONOutput     = "json"
+	CSVOutput      = "csv"
+	TSVOutput      = "tsv"
+	MarkdownOutput = "markdown"
+	HTMLOutput     = "html"
+	// ASGPolicyOutput auto scaling group MixedInstancesPolicy, EC2FleetOutput EC2 Fleet LaunchTemplateConfigs
+	ASGPolicyOutput = "asg-policy"
+	EC2FleetOutput  = "ec2-fleet"
+	KarpenterOutput = "karpenter"
+	// TFVarsOutput terraform variables file, HCLJSONOutput the same variables as a .tfvars.json file
+	TFVarsOutput  = "tfvars"
+	HCLJSONOutput = "hcl-json"
+	// ElastigroupOutput spotinst elastigroup compute, OceanOutput spotinst ocean instance types whitelist
+	ElastigroupOutput = "elastigroup"
+	OceanOutput       = "ocean"
+)
+
+const (
+	HTTPSource = "http"
+	FileSource = "file"
+)
+
 const (
 	SpotAdvisorJSONURL = "https://spot-bid-advisor.s3.amazonaws.com/spot-advisor-data.json"
 	SpotPriceJsURL     = "https://spot-price.s3.amazonaws.com/spot.js"
 )
 
+// AvailablespotinstAzs static zone table, used when the zones of a region can't be discovered
 var (
-	AvailablespotinstAzs = map[string][]string{"us-east-1": {
-		"us-east-1a",
-		"us-east-1b",
-		"us-east-1c",
-		"us-east-1d",
-		"us-east-1f",
-	},
+	AvailablespotinstAzs = map[string][]string{
+		"us-east-1":      {"us-east-1a", "us-east-1b", "us-east-1c", "us-east-1d", "us-east-1f"},
+		"us-east-2":      {"us-east-2a", "us-east-2b", "us-east-2c"},
+		"us-west-1":      {"us-west-1a", "us-west-1c"},
+		"us-west-2":      {"us-west-2a", "us-west-2b", "us-west-2c", "us-west-2d"},
+		"ca-central-1":   {"ca-central-1a", "ca-central-1b", "ca-central-1d"},
+		"eu-west-1":      {"eu-west-1a", "eu-west-1b", "eu-west-1c"},
+		"eu-west-2":      {"eu-west-2a", "eu-west-2b", "eu-west-2c"},
+		"eu-west-3":      {"eu-west-3a", "eu-west-3b", "eu-west-3c"},
+		"eu-central-1":   {"eu-central-1a", "eu-central-1b", "eu-central-1c"},
+		"eu-north-1":     {"eu-north-1a", "eu-north-1b", "eu-north-1c"},
+		"ap-south-1":     {"ap-south-1a", "ap-south-1b", "ap-south-1c"},
+		"ap-southeast-1": {"ap-southeast-1a", "ap-southeast-1b", "ap-southeast-1c"},
+		"ap-southeast-2": {"ap-southeast-2a", "ap-southeast-2b", "ap-southeast-2c"},
+		"ap-northeast-1": {"ap-northeast-1a", "ap-northeast-1c", "ap-northeast-1d"},
+		"ap-northeast-2": {"ap-northeast-2a", "ap-northeast-2b", "ap-northeast-2c", "ap-northeast-2d"},
+		"sa-east-1":      {"sa-east-1a", "sa-east-1b", "sa-east-1c"},
 	}
 )
@@ -1,3 +0,0 @@
-package known
-
-var SPotinstUserToken = ""
@@ -1,15 +1,47 @@
 package models
 
+import (
+	"spotinfo/pkg/instancetype"
+	"time"
+)
+
 // Advice - spot price advice: interruption range and savings
 type Advice struct {
-	Region    string
-	Instance  string
-	Range     InterruptionRange
-	Savings   int
-	Info      TypeInfo
-	Price     float64
-	Score     map[string]int
-	ZonePrice map[string]float64
+	Region   string            `json:"region"`
+	Instance string            `json:"instance"`
+	Range    InterruptionRange `json:"range"`
+	Savings  int               `json:"savings"`
+	Info     TypeInfo          `json:"info"`
+	// Type metadata parsed from the instance type name
+	Type  instancetype.Info `json:"type"`
+	Price float64           `json:"price"`
+	// Score market scores per AZ, score mode only
+	Score map[string]LifetimeScores `json:"score,omitempty"`
+	// ZonePrice spot price per AZ when the pricing feed has one
+	ZonePrice map[string]float64 `json:"zone_price,omitempty"`
+}
+
+// LifetimeScores Spotinst market score per minimum instance lifetime in hours
+type LifetimeScores map[int]int
+
+// Min the lowest score over all lifetimes, the most conservative one
+func (s LifetimeScores) Min() int {
+	first := true
+	var result int
+	for _, score := range s {
+		if first || score < result {
+			result, first = score, false
+		}
+	}
+	return result
+}
+
+// FeedStatus when a feed was fetched, stale feeds are expired cached copies
+// served because fetching failed
+type FeedStatus struct {
+	Feed      string    `json:"feed"`
+	FetchedAt time.Time `json:"fetched_at"`
+	Stale     bool      `json:"stale"`
 }
 
 // InterruptionRange range
@@ -29,12 +61,13 @@ type InstanceType struct {
 }
 
 type AdvisorData struct {
-	Ranges        []interruptionRange     `json:"ranges"`
+	Ranges        []AdvisorRange          `json:"ranges"`
 	InstanceTypes map[string]instanceType `json:"instance_types"` //nolint:tagliatelle
 	Regions       map[string]osTypes      `json:"spot_advisor"`   //nolint:tagliatelle
 }
 
-type interruptionRange struct {
+// AdvisorRange interruption band of the advisor data
+type AdvisorRange struct {
 	Label string `json:"label"`
 	Index int    `json:"index"`
 	Dots  int    `json:"dots"`
@@ -56,3 +89,17 @@ type osTypes struct {
 	Windows map[string]SpotInfo `json:"Windows"` //nolint:tagliatelle
 	Linux   map[string]SpotInfo `json:"Linux"`   //nolint:tagliatelle
 }
+
+// SpotPriceData spot pricing: region -> instance type -> price per OS
+type SpotPriceData struct {
+	Region map[string]RegionPrice `json:"region"`
+}
+
+type RegionPrice struct {
+	Instance map[string]InstancePrice `json:"instance"`
+}
+
+type InstancePrice struct {
+	Linux   float64 `json:"linux"`
+	Windows float64 `json:"windows"`
+}
@@ -7,9 +7,13 @@ type SpotinstScores struct {
 	SS   []SpotinstScore
 }
 type SpotinstScore struct {
-	Az           string
-	Score        int
-	InstanceType string
+	Az           string `json:"az"`
+	Score        int    `json:"score"`
+	InstanceType string `json:"instance_type"`
+	// Lifetime minimum instance lifetime in hours the score is given for
+	Lifetime int `json:"lifetime"`
+	// Product EC2 product the score is given for, e.g. Linux/UNIX (Amazon VPC)
+	Product string `json:"product"`
 }
 
 type SpotinstScoreResp struct {
@@ -25,3 +29,24 @@ type SpotinstScoreResp struct {
 	} `json:"items"`
 	RegistrationState int `json:"registrationState"`
 }
+
+// SpotinstAccount account visible to a Spotinst token
+type SpotinstAccount struct {
+	AccountId      string `json:"accountId"`
+	Name           string `json:"name"`
+	OrganizationId string `json:"organizationId"`
+}
+
+type SpotinstAccountsResp struct {
+	Response struct {
+		Items []SpotinstAccount `json:"items"`
+	} `json:"response"`
+}
+
+type SpotinstZonesResp struct {
+	Response struct {
+		Items []struct {
+			Name string `json:"name"`
+		} `json:"items"`
+	} `json:"response"`
+}
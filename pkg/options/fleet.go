@@ -0,0 +1,77 @@
+package options
+
+import (
+	"github.com/spf13/pflag"
+	"spotinfo/pkg/fleet"
+	"spotinfo/pkg/known"
+	"spotinfo/pkg/recommend"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// FleetOptions options of the fleet subcommand, the filters come from SpotinstOptions
+type FleetOptions struct {
+	Capacity     float64
+	Unit         string
+	MaxTypes     int
+	MaxPerFamily int
+	MaxZones     int
+	Weights      string
+	Output       string
+}
+
+func NewFleetOptions() *FleetOptions {
+	return &FleetOptions{}
+}
+
+func (o *FleetOptions) AddFlags(flags *pflag.FlagSet) {
+	flags.Float64Var(&o.Capacity, "capacity", 0, "target capacity of every region in --unit")
+	flags.StringVar(&o.Unit, "unit", fleet.VCPUUnit, "capacity unit "+fleet.VCPUUnit+"|"+fleet.MemoryUnit+", memory in GiB")
+	flags.IntVar(&o.MaxTypes, "max-types", 6, "instance types per fleet")
+	flags.IntVar(&o.MaxPerFamily, "max-per-family", 2, "instance types of the same family per fleet, 0 for no limit")
+	flags.IntVar(&o.MaxZones, "max-zones", 3, "best scored AZs every instance type is spread over, 0 for all of them")
+	flags.StringVar(&o.Weights, "weights", "", "ranking weights of the candidates, see the recommend subcommand")
+	flags.StringVar(&o.Output, "output", known.TableOutput, "output format table|json|asg-policy|ec2-fleet")
+}
+
+// Validate check the fleet options
+func (o *FleetOptions) Validate() error {
+	if o.Capacity <= 0 {
+		return errors.New("--capacity must be positive")
+	}
+	switch o.Unit {
+	case fleet.VCPUUnit, fleet.MemoryUnit:
+	default:
+		return errors.Errorf("invalid --unit %q, must be %s|%s", o.Unit, fleet.VCPUUnit, fleet.MemoryUnit)
+	}
+	if o.MaxTypes < 1 {
+		return errors.New("--max-types must be at least 1")
+	}
+	if o.MaxPerFamily < 0 || o.MaxZones < 0 {
+		return errors.New("--max-per-family and --max-zones can't be negative")
+	}
+	if _, err := recommend.ParseWeights(o.Weights); err != nil {
+		return errors.Wrap(err, "invalid --weights")
+	}
+	switch strings.ToLower(o.Output) {
+	case known.TableOutput, known.JSONOutput, known.ASGPolicyOutput, known.EC2FleetOutput:
+	default:
+		return errors.Errorf("invalid --output %q, must be %s|%s|%s|%s", o.Output,
+			known.TableOutput, known.JSONOutput, known.ASGPolicyOutput, known.EC2FleetOutput)
+	}
+	return nil
+}
+
+// Spec the fleet spec of the options, Validate first
+func (o *FleetOptions) Spec() fleet.Spec {
+	weights, _ := recommend.ParseWeights(o.Weights)
+	return fleet.Spec{
+		Unit:         o.Unit,
+		Target:       o.Capacity,
+		MaxTypes:     o.MaxTypes,
+		MaxPerFamily: o.MaxPerFamily,
+		MaxZones:     o.MaxZones,
+		Weights:      weights,
+	}
+}
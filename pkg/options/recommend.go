@@ -0,0 +1,43 @@
+package options
+
+import (
+	"github.com/spf13/pflag"
+	"spotinfo/pkg/known"
+	"spotinfo/pkg/recommend"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// RecommendOptions options of the recommend subcommand, the filters come from SpotinstOptions
+type RecommendOptions struct {
+	Top     int
+	Weights string
+	Output  string
+}
+
+func NewRecommendOptions() *RecommendOptions {
+	return &RecommendOptions{}
+}
+
+func (o *RecommendOptions) AddFlags(flags *pflag.FlagSet) {
+	flags.IntVar(&o.Top, "top", 5, "instance types to recommend per region, 0 for all of them")
+	flags.StringVar(&o.Weights, "weights", "", "ranking weights, e.g. savings=0.4,interruption=0.3,price=0.1,score=0.2 (default savings=0.3,interruption=0.3,price=0.2,score=0.2)")
+	flags.StringVar(&o.Output, "output", known.TableOutput, "output format table|json")
+}
+
+// Validate check the ranking options
+func (o *RecommendOptions) Validate() error {
+	if o.Top < 0 {
+		return errors.New("--top can't be negative")
+	}
+	if _, err := recommend.ParseWeights(o.Weights); err != nil {
+		return errors.Wrap(err, "invalid --weights")
+	}
+	switch strings.ToLower(o.Output) {
+	case known.TableOutput, known.JSONOutput:
+	default:
+		return errors.Errorf("invalid --output %q, must be %s|%s", o.Output, known.TableOutput, known.JSONOutput)
+	}
+	return nil
+}
@@ -2,26 +2,76 @@ package options
 
 import (
 	"github.com/spf13/pflag"
+	"os"
+	"regexp"
+	"spotinfo/pkg/instancetype"
+	"spotinfo/pkg/known"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
 )
 
 type SpotinstOptions struct {
-	UserName  string
-	Password  string
+	UserName         string
+	Password         string
+	SpotinstAccount  string
+	SpotinstEndpoint string
+	ScoreConcurrency int
+	ScoreBatchSize   int
+	Retries          int
+	RetryBackoff     time.Duration
+	RateLimit        float64
+
 	Type      string
 	Region    []string
 	Mode      string
+	MinCpu    int
 	MaxCpu    int
-	MaxMemory int
-	Sort      string
-	Order     string
-	Os        string
-}
+	MinMemory float64
+	MaxMemory float64
+	// memory GiB per vCPU
+	MinMemoryPerCpu float64
+	MaxMemoryPerCpu float64
+	MinSavings      int
+	MaxPrice        float64
+	// MaxInterruption interruption band label like <5% or max percentage like 10
+	MaxInterruption string
+	MinScore        int
+	ScoreMatch      string
+	// instance type metadata filters
+	Families         []string
+	MinGeneration    int
+	Arch             string
+	ExcludeBurstable bool
+	Sort             string
+	Order            string
+	Os               string
+	Source           string
+	SourceDir        string
+	Output           string
+	OutputFile       string
+	Columns          []string
+	// LaunchTemplate launch template name of the asg-policy and ec2-fleet outputs
+	LaunchTemplate string
+	// TargetCapacity total vCPUs of the ec2-fleet output
+	TargetCapacity int
+	// karpenter output
+	NodePool                string
+	NodeClass               string
+	KarpenterZones          int
+	ExcludeHighInterruption bool
 
-var defaultAzs = []string{
-	"us-east-1b",
-	"us-east-1c",
-	"us-east-1d",
-	"us-east-1f",
+	FailOnPartial bool
+	Lifetimes     []int
+
+	CacheDir   string
+	AdvisorTTL time.Duration
+	PriceTTL   time.Duration
+	ScoreTTL   time.Duration
+	ZonesTTL   time.Duration
+	MaxStale   time.Duration
 }
 
 func NewSpotinstOptions() *SpotinstOptions {
@@ -29,12 +79,154 @@ func NewSpotinstOptions() *SpotinstOptions {
 }
 
 func (o *SpotinstOptions) AddFlags(flags *pflag.FlagSet) {
+	o.AddFilterFlags(flags)
+	flags.StringVarP(&o.Sort, "sort", "s", "interruption", "sort keys with an optional order, e.g. score:desc,price:asc, keys: interruption|type|savings|price|region|score|az|az-price|price-per-vcpu|price-per-gib")
+	flags.StringVarP(&o.Order, "order", "o", "desc", "sort order asc|desc of the sort keys without one")
+	flags.StringVar(&o.Output, "output", "table", "output format table|json|csv|tsv|markdown|html|asg-policy|ec2-fleet|karpenter|tfvars|hcl-json|elastigroup|ocean")
+	flags.StringVar(&o.OutputFile, "output-file", "", "write the output to this file instead of stdout, e.g. spot.auto.tfvars")
+	flags.StringSliceVar(&o.Columns, "columns", nil, "columns to print in this order, any of region,az,instance,vcpu,memory,savings,interruption,interruption-min,interruption-max,emr,score,price (default depends on --mode and --region)")
+	o.AddLaunchTemplateFlags(flags)
+	flags.IntVar(&o.TargetCapacity, "target-capacity", 0, "total target capacity in vCPUs of the ec2-fleet output, required by EC2 Fleet")
+	flags.StringVar(&o.NodePool, "nodepool", "spot", "NodePool name of the karpenter output")
+	flags.StringVar(&o.NodeClass, "node-class", "default", "EC2NodeClass the karpenter NodePool refers to")
+	flags.IntVar(&o.KarpenterZones, "karpenter-zones", 0, "best scored AZs the karpenter NodePool requires, 0 for all the scored ones")
+	flags.BoolVar(&o.ExcludeHighInterruption, "exclude-high-interruption", false, "leave the instance types interrupted more than 20% of the time out of the karpenter NodePool")
+}
+
+// AddLaunchTemplateFlags flags of the asg-policy and ec2-fleet outputs, shared with the fleet subcommand
+func (o *SpotinstOptions) AddLaunchTemplateFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&o.LaunchTemplate, "launch-template", "LAUNCH_TEMPLATE_NAME", "launch template name of the asg-policy and ec2-fleet outputs")
+}
+
+// AddFilterFlags data source and filter flags, shared with the recommend subcommand
+func (o *SpotinstOptions) AddFilterFlags(flags *pflag.FlagSet) {
 	flags.StringVarP(&o.Type, "instance_type", "i", "", "EC2 instance type (can be RE2 regexp patten)")
 	flags.StringSliceVarP(&o.Region, "region", "r", []string{"all"}, "set one or more AWS regions, use \"all\" for all AWS regions")
-	flags.IntVarP(&o.MaxCpu, "cpu", "c", 0, "filter: minimal vCPU cores")
-	flags.IntVarP(&o.MaxMemory, "memory", "m", 0, "filter: minimal memory GiB")
-	flags.StringVarP(&o.Sort, "sort", "s", "s", "sort results by interruption|type|savings|price|region|score")
-	flags.StringVarP(&o.Order, "order", "o", "desc", "sort order asc|desc")
-	flags.StringVar(&o.Os, "os", "Linux", "os type")
+	flags.IntVar(&o.MinCpu, "min-cpu", 0, "filter: minimal vCPU cores")
+	flags.IntVar(&o.MaxCpu, "max-cpu", 0, "filter: maximal vCPU cores, 0 for no limit")
+	flags.Float64Var(&o.MinMemory, "min-memory", 0, "filter: minimal memory GiB")
+	flags.Float64Var(&o.MaxMemory, "max-memory", 0, "filter: maximal memory GiB, 0 for no limit")
+	flags.Float64Var(&o.MinMemoryPerCpu, "min-memory-per-cpu", 0, "filter: minimal memory GiB per vCPU, e.g. 4 skips compute optimized types")
+	flags.Float64Var(&o.MaxMemoryPerCpu, "max-memory-per-cpu", 0, "filter: maximal memory GiB per vCPU, 0 for no limit")
+	flags.IntVar(&o.MinSavings, "min-savings", 0, "filter: minimal savings over on-demand percentage")
+	flags.Float64Var(&o.MaxPrice, "max-price", 0, "filter: maximal spot price USD/hour, 0 for no limit")
+	flags.StringVar(&o.MaxInterruption, "max-interruption", "", "filter: maximal frequency of interruption, a band like \"<5%\" or \"10-15%\", or a percentage rounded up to the band holding it, e.g. 6 keeps the 5-10% band reaching 11%")
+	flags.IntVar(&o.MinScore, "min-score", 0, "filter: minimal spotinst market score over all lifetimes, score mode only")
+	flags.StringVar(&o.ScoreMatch, "score-match", known.AnyZone, "AZs that must reach --min-score: any|all")
+	flags.StringSliceVar(&o.Families, "family", nil, "filter: instance families like m,c,r or classes like m6g")
+	flags.IntVar(&o.MinGeneration, "min-generation", 0, "filter: minimal instance generation, e.g. 6")
+	flags.StringVar(&o.Arch, "arch", "", "filter: processor architecture "+instancetype.ARM64+"|"+instancetype.X8664)
+	flags.BoolVar(&o.ExcludeBurstable, "exclude-burstable", false, "filter: skip burstable (t) instance types")
+	// --cpu and --memory always filtered by maximum, whatever their help said
+	flags.IntVarP(&o.MaxCpu, "cpu", "c", 0, "filter: maximal vCPU cores")
+	flags.Float64VarP(&o.MaxMemory, "memory", "m", 0, "filter: maximal memory GiB")
+	_ = flags.MarkDeprecated("cpu", "it filters by maximum vCPU, use --max-cpu or --min-cpu")
+	_ = flags.MarkDeprecated("memory", "it filters by maximum memory, use --max-memory or --min-memory")
+	flags.StringVar(&o.Os, "os", "Linux", "os type: linux|windows|rhel|suse, rhel and suse use the linux advisor and pricing data")
 	flags.StringVar(&o.Mode, "mode", "score", "score|normal")
+	flags.IntSliceVar(&o.Lifetimes, "lifetime", []int{1}, "minimum instance lifetimes in hours to score, e.g. 1,4,8,24")
+	flags.BoolVar(&o.FailOnPartial, "fail-on-partial", false, "fail when some spotinst score requests fail instead of printing partial scores")
+	flags.StringVar(&o.Source, "source", "http", "data source http|file")
+	flags.StringVar(&o.SourceDir, "source-dir", "", "directory with captured spot-advisor-data.json, spot.js and score.json snapshots, used by --source file")
+}
+
+// AddCacheFlags cache flags are shared with the cache subcommands
+func (o *SpotinstOptions) AddCacheFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&o.CacheDir, "cache-dir", "", "feed cache directory (default $XDG_CACHE_HOME/spotinfo)")
+	flags.DurationVar(&o.AdvisorTTL, "advisor-ttl", 24*time.Hour, "time to live of the cached spot advisor data")
+	flags.DurationVar(&o.PriceTTL, "price-ttl", 24*time.Hour, "time to live of the cached spot pricing data")
+	flags.DurationVar(&o.ScoreTTL, "score-ttl", 24*time.Hour, "time to live of the cached spotinst market scores")
+	flags.DurationVar(&o.ZonesTTL, "zones-ttl", 7*24*time.Hour, "time to live of the discovered availability zones")
+	flags.DurationVar(&o.MaxStale, "max-stale", 7*24*time.Hour, "max age of an expired cached feed used when the remote feed is unreachable, 0 disables the fallback")
+}
+
+// AddSpotinstFlags spotinst credentials are shared with the cache subcommands
+func (o *SpotinstOptions) AddSpotinstFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&o.UserName, "username", "", "spotinst console user, signs in when env SpotinstAccessToken is not set")
+	flags.StringVar(&o.Password, "password", "", "spotinst console password")
+	flags.StringVar(&o.SpotinstAccount, "spotinst-account", envOr(known.SpotAccountEnv, known.SpotAccountId), "spotinst account id, env "+known.SpotAccountEnv)
+	flags.StringVar(&o.SpotinstEndpoint, "spotinst-endpoint", envOr(known.SpotEndpointEnv, known.SpotHost), "spotinst API host, env "+known.SpotEndpointEnv)
+	flags.IntVar(&o.ScoreConcurrency, "score-concurrency", 10, "concurrent spotinst market score requests")
+	flags.IntVar(&o.ScoreBatchSize, "score-batch-size", 50, "instance types per spotinst market score request")
+	flags.IntVar(&o.Retries, "retries", 3, "retries of spotinst requests failing with network errors, 429 or 5xx")
+	flags.DurationVar(&o.RetryBackoff, "retry-backoff", 500*time.Millisecond, "base delay between retries, doubled on every retry")
+	flags.Float64Var(&o.RateLimit, "rate-limit", 5, "max spotinst requests per second, 0 disables the limit")
+}
+
+// Validate check the filter ranges
+func (o *SpotinstOptions) Validate() error {
+	if o.MinCpu < 0 || o.MaxCpu < 0 {
+		return errors.New("--min-cpu and --max-cpu can't be negative")
+	}
+	if o.MaxCpu != 0 && o.MinCpu > o.MaxCpu {
+		return errors.Errorf("--min-cpu %d is greater than --max-cpu %d", o.MinCpu, o.MaxCpu)
+	}
+	if o.MinMemory < 0 || o.MaxMemory < 0 {
+		return errors.New("--min-memory and --max-memory can't be negative")
+	}
+	if o.MaxMemory != 0 && o.MinMemory > o.MaxMemory {
+		return errors.Errorf("--min-memory %g is greater than --max-memory %g", o.MinMemory, o.MaxMemory)
+	}
+	if o.MinMemoryPerCpu < 0 || o.MaxMemoryPerCpu < 0 {
+		return errors.New("--min-memory-per-cpu and --max-memory-per-cpu can't be negative")
+	}
+	if o.MaxMemoryPerCpu != 0 && o.MinMemoryPerCpu > o.MaxMemoryPerCpu {
+		return errors.Errorf("--min-memory-per-cpu %g is greater than --max-memory-per-cpu %g", o.MinMemoryPerCpu, o.MaxMemoryPerCpu)
+	}
+	if o.MinSavings < 0 || o.MinSavings > 100 {
+		return errors.Errorf("--min-savings %d must be between 0 and 100", o.MinSavings)
+	}
+	if o.MaxPrice < 0 {
+		return errors.New("--max-price can't be negative")
+	}
+	if o.MaxInterruption != "" && !strings.ContainsAny(o.MaxInterruption, "<>-") {
+		if _, err := ParsePercent(o.MaxInterruption); err != nil {
+			return errors.Wrap(err, "invalid --max-interruption")
+		}
+	}
+	if o.MinScore < 0 || o.MinScore > 100 {
+		return errors.Errorf("--min-score %d must be between 0 and 100", o.MinScore)
+	}
+	if o.MinScore > 0 && o.Mode != known.ScoreMode {
+		return errors.Errorf("--min-score needs --mode %s", known.ScoreMode)
+	}
+	if _, err := regexp.Compile(o.Type); err != nil {
+		return errors.Wrap(err, "invalid --instance_type")
+	}
+	if o.MinGeneration < 0 {
+		return errors.New("--min-generation can't be negative")
+	}
+	switch o.Arch {
+	case "", instancetype.ARM64, instancetype.X8664:
+	default:
+		return errors.Errorf("invalid --arch %q, must be %s|%s", o.Arch, instancetype.ARM64, instancetype.X8664)
+	}
+	if o.TargetCapacity < 0 {
+		return errors.New("--target-capacity can't be negative")
+	}
+	if o.KarpenterZones < 0 {
+		return errors.New("--karpenter-zones can't be negative")
+	}
+	switch o.ScoreMatch {
+	case known.AnyZone, known.AllZones, "":
+	default:
+		return errors.Errorf("invalid --score-match %q, must be %s|%s", o.ScoreMatch, known.AnyZone, known.AllZones)
+	}
+	return nil
+}
+
+// ParsePercent parse a percentage like 10 or 10%
+func ParsePercent(s string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(s), "%"))
+	if err != nil || n < 0 || n > 100 {
+		return 0, errors.Errorf("%q is not a percentage between 0 and 100", s)
+	}
+	return n, nil
+}
+
+func envOr(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+	return def
 }
@@ -0,0 +1,27 @@
+package options
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  SpotinstOptions
+		valid bool
+	}{
+		{name: "no filter", valid: true},
+		{name: "ranges", opts: SpotinstOptions{MinCpu: 2, MaxCpu: 8, MinMemory: 4, MaxMemory: 32, MinMemoryPerCpu: 2, MaxMemoryPerCpu: 8}, valid: true},
+		{name: "unlimited max", opts: SpotinstOptions{MinCpu: 64, MinMemory: 512}, valid: true},
+		{name: "negative cpu", opts: SpotinstOptions{MinCpu: -1}},
+		{name: "cpu min over max", opts: SpotinstOptions{MinCpu: 8, MaxCpu: 4}},
+		{name: "memory min over max", opts: SpotinstOptions{MinMemory: 16, MaxMemory: 8}},
+		{name: "ratio min over max", opts: SpotinstOptions{MinMemoryPerCpu: 8, MaxMemoryPerCpu: 4}},
+		{name: "negative ratio", opts: SpotinstOptions{MaxMemoryPerCpu: -2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.Validate(); (err == nil) != tt.valid {
+				t.Errorf("got %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package recommend
+
+import (
+	"fmt"
+	"sort"
+	"spotinfo/pkg/models"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// weight names accepted by ParseWeights
+const (
+	SavingsWeight      = "savings"
+	InterruptionWeight = "interruption"
+	PriceWeight        = "price"
+	ScoreWeight        = "score"
+)
+
+// Weights relative weight of every criterion, they don't need to add up to 1
+type Weights struct {
+	Savings      float64 `json:"savings"`
+	Interruption float64 `json:"interruption"`
+	Price        float64 `json:"price"`
+	Score        float64 `json:"score"`
+}
+
+// DefaultWeights favor savings and a low interruption frequency
+var DefaultWeights = Weights{Savings: 0.3, Interruption: 0.3, Price: 0.2, Score: 0.2}
+
+// ParseWeights parse weights like savings=0.4,score=0.3, the missing ones keep their default
+func ParseWeights(spec string) (Weights, error) {
+	w := DefaultWeights
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return w, errors.Errorf("invalid weight %q, must be name=value", field)
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || v < 0 {
+			return w, errors.Errorf("invalid weight %q, must be a positive number", field)
+		}
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case SavingsWeight:
+			w.Savings = v
+		case InterruptionWeight:
+			w.Interruption = v
+		case PriceWeight:
+			w.Price = v
+		case ScoreWeight:
+			w.Score = v
+		default:
+			return w, errors.Errorf("invalid weight name %q, must be %s|%s|%s|%s", name,
+				SavingsWeight, InterruptionWeight, PriceWeight, ScoreWeight)
+		}
+	}
+	if w.Savings+w.Interruption+w.Price+w.Score == 0 {
+		return w, errors.New("at least one weight must be positive")
+	}
+	return w, nil
+}
+
+// Components criteria ratings between 0 (worst) and 1 (best)
+type Components struct {
+	Savings      float64 `json:"savings"`
+	Interruption float64 `json:"interruption"`
+	Price        float64 `json:"price"`
+	Score        float64 `json:"score"`
+}
+
+// Recommendation a ranked advice
+type Recommendation struct {
+	// Rank 1 for the best candidate of the region
+	Rank       int        `json:"rank"`
+	Composite  float64    `json:"composite"`
+	Components Components `json:"components"`
+	// BestZone AZ with the highest score, BestScore its lowest score over the lifetimes
+	BestZone  string        `json:"best_zone,omitempty"`
+	BestScore int           `json:"best_score,omitempty"`
+	Reason    string        `json:"reason"`
+	Advice    models.Advice `json:"advice"`
+}
+
+// Rank rate the advices of every region with w and keep the top n of each region, all of them when n <= 0.
+// Price is rated per vCPU against the cheapest candidate of the region. When no advice has scores,
+// e.g. in normal mode, the score weight is left out
+func Rank(advices []models.Advice, w Weights, n int) []Recommendation {
+	regions := make(map[string][]models.Advice)
+	var names []string
+	for _, advice := range advices {
+		if _, ok := regions[advice.Region]; !ok {
+			names = append(names, advice.Region)
+		}
+		regions[advice.Region] = append(regions[advice.Region], advice)
+	}
+	sort.Strings(names)
+	var result []Recommendation
+	for _, region := range names {
+		result = append(result, rankRegion(regions[region], w, n)...)
+	}
+	return result
+}
+
+func rankRegion(advices []models.Advice, w Weights, n int) []Recommendation {
+	cheapest, scored := 0.0, false
+	for i := range advices {
+		if p := pricePerCPU(&advices[i]); p > 0 && (cheapest == 0 || p < cheapest) {
+			cheapest = p
+		}
+		if len(advices[i].Score) > 0 {
+			scored = true
+		}
+	}
+	if !scored {
+		w.Score = 0
+	}
+	total := w.Savings + w.Interruption + w.Price + w.Score
+	recs := make([]Recommendation, 0, len(advices))
+	for i := range advices {
+		advice := &advices[i]
+		var c Components
+		c.Savings = clamp(float64(advice.Savings) / 100)
+		c.Interruption = clamp(1 - float64(advice.Range.Max)/100)
+		if p := pricePerCPU(advice); p > 0 {
+			c.Price = cheapest / p
+		}
+		zone, score := bestZone(advice)
+		c.Score = float64(score) / 100
+		composite := 0.0
+		if total > 0 {
+			composite = (w.Savings*c.Savings + w.Interruption*c.Interruption + w.Price*c.Price + w.Score*c.Score) / total
+		}
+		recs = append(recs, Recommendation{
+			Composite:  composite,
+			Components: c,
+			BestZone:   zone,
+			BestScore:  score,
+			Reason:     explain(advice, c, w, total, zone, score),
+			Advice:     *advice,
+		})
+	}
+	sort.SliceStable(recs, func(i, j int) bool {
+		if recs[i].Composite != recs[j].Composite {
+			return recs[i].Composite > recs[j].Composite
+		}
+		return recs[i].Advice.Instance < recs[j].Advice.Instance
+	})
+	if n > 0 && len(recs) > n {
+		recs = recs[:n]
+	}
+	for i := range recs {
+		recs[i].Rank = i + 1
+	}
+	return recs
+}
+
+func pricePerCPU(advice *models.Advice) float64 {
+	if advice.Price <= 0 || advice.Info.Cores <= 0 {
+		return 0
+	}
+	return advice.Price / float64(advice.Info.Cores)
+}
+
+// bestZone the AZ with the highest score, the lowest score over the lifetimes counting
+func bestZone(advice *models.Advice) (zone string, score int) {
+	zones := make([]string, 0, len(advice.Score))
+	for az := range advice.Score {
+		zones = append(zones, az)
+	}
+	sort.Strings(zones)
+	for _, az := range zones {
+		if s := advice.Score[az]; len(s) > 0 && (zone == "" || s.Min() > score) {
+			zone, score = az, s.Min()
+		}
+	}
+	return zone, score
+}
+
+func clamp(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+// explain the points every criterion brings to the composite
+func explain(advice *models.Advice, c Components, w Weights, total float64, zone string, score int) string {
+	if total == 0 {
+		return ""
+	}
+	points := func(weight, rating float64) float64 { return weight * rating / total }
+	parts := []string{
+		fmt.Sprintf("savings %d%% +%.2f", advice.Savings, points(w.Savings, c.Savings)),
+		fmt.Sprintf("interruption %s +%.2f", advice.Range.Label, points(w.Interruption, c.Interruption)),
+	}
+	if advice.Price > 0 {
+		parts = append(parts, fmt.Sprintf("$%.4f/vCPU +%.2f", pricePerCPU(advice), points(w.Price, c.Price)))
+	} else {
+		parts = append(parts, "no price +0.00")
+	}
+	if w.Score > 0 {
+		if zone != "" {
+			parts = append(parts, fmt.Sprintf("score %d in %s +%.2f", score, zone, points(w.Score, c.Score)))
+		} else {
+			parts = append(parts, "no score +0.00")
+		}
+	}
+	return strings.Join(parts, ", ")
+}
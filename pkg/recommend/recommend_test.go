@@ -0,0 +1,67 @@
+package recommend
+
+import (
+	"math"
+	"spotinfo/pkg/models"
+	"strings"
+	"testing"
+)
+
+func TestParseWeights(t *testing.T) {
+	w, err := ParseWeights("savings=0.5, Score=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Weights{Savings: 0.5, Interruption: DefaultWeights.Interruption, Price: DefaultWeights.Price}
+	if w != want {
+		t.Errorf("got %+v, want %+v", w, want)
+	}
+	for _, spec := range []string{"savings", "cost=1", "price=-1", "savings=0,interruption=0,price=0,score=0"} {
+		if _, err := ParseWeights(spec); err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+}
+
+func TestRank(t *testing.T) {
+	advices := []models.Advice{
+		{Region: "us-east-1", Instance: "cheap", Savings: 70, Range: models.InterruptionRange{Label: "<5%", Max: 5},
+			Price: 0.04, Info: models.TypeInfo{Cores: 2},
+			Score: map[string]models.LifetimeScores{"us-east-1a": {1: 40}, "us-east-1b": {1: 80, 4: 60}}},
+		{Region: "us-east-1", Instance: "risky", Savings: 80, Range: models.InterruptionRange{Label: ">20%", Max: 100},
+			Price: 0.04, Info: models.TypeInfo{Cores: 2}},
+		{Region: "us-east-1", Instance: "pricey", Savings: 70, Range: models.InterruptionRange{Label: "<5%", Max: 5},
+			Price: 0.08, Info: models.TypeInfo{Cores: 2},
+			Score: map[string]models.LifetimeScores{"us-east-1a": {1: 60}}},
+		{Region: "eu-west-1", Instance: "only", Savings: 50, Range: models.InterruptionRange{Label: "5-10%", Max: 10},
+			Price: 0.05, Info: models.TypeInfo{Cores: 2}},
+	}
+	recs := Rank(advices, DefaultWeights, 2)
+	var got []string
+	for _, rec := range recs {
+		got = append(got, rec.Advice.Region+"/"+rec.Advice.Instance)
+	}
+	if strings.Join(got, ",") != "eu-west-1/only,us-east-1/cheap,us-east-1/pricey" {
+		t.Fatalf("got %v", got)
+	}
+	if recs[0].Rank != 1 || recs[1].Rank != 1 || recs[2].Rank != 2 {
+		t.Errorf("unexpected ranks %d %d %d", recs[0].Rank, recs[1].Rank, recs[2].Rank)
+	}
+	// the best AZ counts with its lowest score over the lifetimes
+	cheap := recs[1]
+	if cheap.BestZone != "us-east-1b" || cheap.BestScore != 60 {
+		t.Errorf("got best zone %s %d", cheap.BestZone, cheap.BestScore)
+	}
+	want := 0.3*0.7 + 0.3*0.95 + 0.2*1 + 0.2*0.6
+	if math.Abs(cheap.Composite-want) > 1e-9 {
+		t.Errorf("got composite %v, want %v", cheap.Composite, want)
+	}
+	if !strings.Contains(cheap.Reason, "score 60 in us-east-1b") {
+		t.Errorf("unexpected reason %q", cheap.Reason)
+	}
+	// eu-west-1 has no scores, the score weight is left out
+	only := recs[0]
+	if math.Abs(only.Composite-(0.3*0.5+0.3*0.9+0.2*1)/0.8) > 1e-9 || strings.Contains(only.Reason, "score") {
+		t.Errorf("unexpected unscored recommendation %+v", only)
+	}
+}
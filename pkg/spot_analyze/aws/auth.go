@@ -0,0 +1,210 @@
+package aws
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/bytedance/sonic"
+	"github.com/cloudwego/hertz/pkg/protocol"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"net/url"
+	"os"
+	"spotinfo/pkg/cache"
+	"spotinfo/pkg/httpclient"
+	"spotinfo/pkg/known"
+	"spotinfo/pkg/options"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// AccessTokenEnv env var overriding the sign-in flow
+	AccessTokenEnv = "SpotinstAccessToken"
+
+	signInTimeout = 30 * time.Second
+	// tokens are renewed a bit before they expire
+	tokenExpiryMargin = time.Minute
+	// used when the sign-in response has no expiry
+	defaultTokenLifetime = time.Hour
+)
+
+// ErrNoCredentials neither an access token nor user credentials were given
+var ErrNoCredentials = errors.Errorf("spotinst credentials missing: set env %s or --username/--password", AccessTokenEnv)
+
+// TokenProvider provides the Spotinst access token
+type TokenProvider interface {
+	// Token return a valid access token
+	Token(ctx context.Context) (string, error)
+	// Invalidate drop the current token, the next Token call gets a new one
+	Invalidate()
+}
+
+// NewTokenProvider the SpotinstAccessToken env var takes precedence over the
+// --username/--password sign-in, an empty one counts as unset
+func NewTokenProvider(opts *options.SpotinstOptions, c *cache.Cache, client *httpclient.Client) (TokenProvider, error) {
+	if token := os.Getenv(AccessTokenEnv); token != "" {
+		return StaticToken(token), nil
+	}
+	if opts.UserName == "" || opts.Password == "" {
+		return nil, ErrNoCredentials
+	}
+	return &SignInTokenProvider{
+		UserName: opts.UserName,
+		Password: opts.Password,
+		Host:     opts.SpotinstEndpoint,
+		Cache:    c,
+		Client:   client,
+	}, nil
+}
+
+// withToken call do with a valid token, on 401 the token is renewed and do is retried once.
+// A StaticToken can't be renewed, its 401 is returned as is
+func withToken(ctx context.Context, tokens TokenProvider, do func(token string) (status int, body []byte, err error)) (status int, body []byte, err error) {
+	token, err := tokens.Token(ctx)
+	if err != nil {
+		return
+	}
+	if status, body, err = do(token); err != nil || status != consts.StatusUnauthorized {
+		return
+	}
+	if _, static := tokens.(StaticToken); static {
+		return
+	}
+	// the token expired or was revoked
+	tokens.Invalidate()
+	if token, err = tokens.Token(ctx); err != nil {
+		return
+	}
+	return do(token)
+}
+
+// StaticToken a fixed token, it can't be renewed
+type StaticToken string
+
+func (t StaticToken) Token(_ context.Context) (string, error) {
+	return string(t), nil
+}
+
+func (t StaticToken) Invalidate() {}
+
+// SignInTokenProvider signs in with user credentials, the token is kept in
+// memory and in the cache until it expires
+type SignInTokenProvider struct {
+	UserName string
+	Password string
+	Host     string
+	Cache    *cache.Cache
+	Client   *httpclient.Client
+
+	mu    sync.Mutex
+	token *accessToken
+}
+
+type accessToken struct {
+	UserName    string    `json:"user_name"`
+	AccessToken string    `json:"access_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func (t *accessToken) valid(userName string) bool {
+	return t != nil && t.UserName == userName && t.AccessToken != "" && time.Until(t.ExpiresAt) > tokenExpiryMargin
+}
+
+type signInResp struct {
+	Response struct {
+		Items []struct {
+			AccessToken string `json:"accessToken"`
+			// seconds
+			ExpiresIn int `json:"expiresIn"`
+		} `json:"items"`
+	} `json:"response"`
+}
+
+// cacheKey the token cache entry of the endpoint and user, hashed so the key does not leak the user name
+func (p *SignInTokenProvider) cacheKey() string {
+	sum := sha256.Sum256([]byte(p.Host + "\n" + p.UserName))
+	return cache.TokenFeed + "-" + hex.EncodeToString(sum[:8])
+}
+
+func (p *SignInTokenProvider) Token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token.valid(p.UserName) {
+		return p.token.AccessToken, nil
+	}
+	if p.Cache != nil {
+		if e, err := p.Cache.Load(p.cacheKey()); err == nil {
+			var token accessToken
+			if e.Decode(&token) == nil && token.valid(p.UserName) {
+				p.token = &token
+				return token.AccessToken, nil
+			}
+		}
+	}
+	token, err := p.signIn(ctx)
+	if err != nil {
+		return "", err
+	}
+	p.token = token
+	if p.Cache != nil {
+		if _, err = p.Cache.Store(cache.TokenFeed, p.cacheKey(), token); err != nil {
+			fmt.Fprintln(os.Stderr, "failed to store cache:", err)
+		}
+	}
+	return token.AccessToken, nil
+}
+
+func (p *SignInTokenProvider) Invalidate() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.token = nil
+	if p.Cache != nil {
+		_ = p.Cache.Remove(p.cacheKey())
+	}
+}
+
+func (p *SignInTokenProvider) signIn(ctx context.Context) (*accessToken, error) {
+	uri, _ := url.JoinPath(p.Host, known.SpotSignUri)
+	req, resp := protocol.AcquireRequest(), protocol.AcquireResponse()
+	defer func() {
+		protocol.ReleaseRequest(req)
+		protocol.ReleaseResponse(resp)
+	}()
+	req.SetMethod(consts.MethodPost)
+	req.SetRequestURI(uri)
+	requestBody, _ := sonic.Marshal(map[string]string{
+		"email":    p.UserName,
+		"password": p.Password,
+	})
+	req.SetBody(requestBody)
+	req.SetHeaders(map[string]string{
+		"Accept":       "application/json, text/plain, */*",
+		"Content-Type": "application/json;charset=UTF-8",
+	})
+	if err := clientOrDefault(p.Client).Do(ctx, req, resp, signInTimeout); err != nil {
+		return nil, errors.Wrap(err, "spotinst sign in failed")
+	}
+	if resp.StatusCode() != consts.StatusOK {
+		return nil, errors.Errorf("spotinst sign in failed, code: %d", resp.StatusCode())
+	}
+	var siResp signInResp
+	if err := sonic.Unmarshal(resp.Body(), &siResp); err != nil {
+		return nil, errors.Wrap(err, "failed to parse spotinst sign in response")
+	}
+	if len(siResp.Response.Items) == 0 || siResp.Response.Items[0].AccessToken == "" {
+		return nil, errors.New("spotinst sign in response has no access token")
+	}
+	item := siResp.Response.Items[0]
+	lifetime := time.Duration(item.ExpiresIn) * time.Second
+	if lifetime <= 0 {
+		lifetime = defaultTokenLifetime
+	}
+	return &accessToken{
+		UserName:    p.UserName,
+		AccessToken: item.AccessToken,
+		ExpiresAt:   time.Now().Add(lifetime),
+	}, nil
+}
@@ -0,0 +1,106 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"spotinfo/pkg/cache"
+	"spotinfo/pkg/known"
+	"spotinfo/pkg/options"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSignInTokenProvider(t *testing.T) {
+	var signIns int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != known.SpotSignUri || r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		n := atomic.AddInt32(&signIns, 1)
+		fmt.Fprintf(w, `{"response":{"items":[{"accessToken":"token-%d","expiresIn":3600}]}}`, n)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := cache.New(t.TempDir(), nil)
+	p := &SignInTokenProvider{UserName: "user", Password: "secret", Host: srv.URL, Cache: c}
+	for i := 0; i < 2; i++ {
+		token, err := p.Token(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "token-1" {
+			t.Errorf("got %s, want token-1", token)
+		}
+	}
+
+	// a new provider reuses the cached token
+	other := &SignInTokenProvider{UserName: "user", Password: "secret", Host: srv.URL, Cache: c}
+	if token, _ := other.Token(ctx); token != "token-1" {
+		t.Errorf("cached token: got %s, want token-1", token)
+	}
+	// but not the one of another user, whose token is cached next to it
+	stranger := &SignInTokenProvider{UserName: "stranger", Password: "secret", Host: srv.URL, Cache: c}
+	if token, _ := stranger.Token(ctx); token != "token-2" {
+		t.Errorf("other user token: got %s, want token-2", token)
+	}
+	other = &SignInTokenProvider{UserName: "user", Password: "secret", Host: srv.URL, Cache: c}
+	if token, _ := other.Token(ctx); token != "token-1" {
+		t.Errorf("cached token after other user: got %s, want token-1", token)
+	}
+	// nor the one of another endpoint
+	elsewhere := &SignInTokenProvider{UserName: "user", Password: "secret", Host: srv.URL + "/", Cache: c}
+	if token, _ := elsewhere.Token(ctx); token != "token-3" {
+		t.Errorf("other endpoint token: got %s, want token-3", token)
+	}
+
+	p.Invalidate()
+	if token, _ := p.Token(ctx); token != "token-4" {
+		t.Errorf("after invalidate: got %s, want token-4", token)
+	}
+	// invalidating drops the token of this endpoint and user only
+	stranger = &SignInTokenProvider{UserName: "stranger", Password: "secret", Host: srv.URL, Cache: c}
+	if token, _ := stranger.Token(ctx); token != "token-2" {
+		t.Errorf("other user token after invalidate: got %s, want token-2", token)
+	}
+}
+
+func TestSignInTokenProviderFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	p := &SignInTokenProvider{UserName: "user", Password: "wrong", Host: srv.URL}
+	if _, err := p.Token(context.Background()); err == nil {
+		t.Error("expected a sign in error")
+	}
+}
+
+func TestWithStaticToken(t *testing.T) {
+	calls := 0
+	status, _, err := withToken(context.Background(), StaticToken("revoked"), func(token string) (int, []byte, error) {
+		calls++
+		return http.StatusUnauthorized, nil, nil
+	})
+	if err != nil || status != http.StatusUnauthorized {
+		t.Errorf("got %d %v, want a 401", status, err)
+	}
+	if calls != 1 {
+		t.Errorf("got %d calls, a static token is not retried", calls)
+	}
+}
+
+func TestNewTokenProviderEmptyEnv(t *testing.T) {
+	t.Setenv(AccessTokenEnv, "")
+	if _, err := NewTokenProvider(&options.SpotinstOptions{}, nil, nil); err != ErrNoCredentials {
+		t.Errorf("got %v, want %v", err, ErrNoCredentials)
+	}
+	t.Setenv(AccessTokenEnv, "token")
+	if tokens, err := NewTokenProvider(&options.SpotinstOptions{}, nil, nil); err != nil || tokens != StaticToken("token") {
+		t.Errorf("got %v %v, want the env token", tokens, err)
+	}
+}
@@ -0,0 +1,130 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"spotinfo/pkg/cache"
+	"spotinfo/pkg/models"
+	"spotinfo/pkg/options"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// CachedSource serves the feeds of Source from an on-disk cache, expired or
+// missing entries are fetched again and stored
+type CachedSource struct {
+	Source Source
+	Cache  *cache.Cache
+	// Refresh ignore cached entries, always fetch and store
+	Refresh bool
+	// MaxStale how old an expired entry may be to be used when fetching fails, 0 disables the fallback
+	MaxStale time.Duration
+
+	status []models.FeedStatus
+}
+
+// NewCachedSource create a source caching the feeds of src in c
+func NewCachedSource(src Source, c *cache.Cache, maxStale time.Duration) *CachedSource {
+	return &CachedSource{Source: src, Cache: c, MaxStale: maxStale}
+}
+
+// FeedStatus when the feeds served so far were fetched, and whether they are stale
+func (s *CachedSource) FeedStatus() []models.FeedStatus {
+	return s.status
+}
+
+func (s *CachedSource) track(feed string, fetchedAt time.Time, stale bool) {
+	s.status = append(s.status, models.FeedStatus{Feed: feed, FetchedAt: fetchedAt, Stale: stale})
+}
+
+// NewCache create the feed cache configured by opts
+func NewCache(opts *options.SpotinstOptions) *cache.Cache {
+	return cache.New(opts.CacheDir, map[string]time.Duration{
+		cache.AdvisorFeed: opts.AdvisorTTL,
+		cache.PriceFeed:   opts.PriceTTL,
+		cache.ScoreFeed:   opts.ScoreTTL,
+		cache.ZonesFeed:   opts.ZonesTTL,
+	})
+}
+
+// cached return the entry stored for key when still fresh, otherwise fetch and
+// store it. When fetching fails the expired entry is served if not older than MaxStale
+func cached[T any](s *CachedSource, feed, key string, fetch func() (T, error)) (result T, err error) {
+	var expired *cache.Entry
+	if !s.Refresh {
+		if e, err := s.Cache.Load(key); err == nil {
+			if !s.Cache.Expired(e) {
+				if err = e.Decode(&result); err == nil {
+					fmt.Fprintf(os.Stderr, "load %s data from cache...\n", feed)
+					s.track(feed, e.FetchedAt, false)
+					return result, nil
+				}
+			} else {
+				expired = e
+			}
+		}
+	}
+	fmt.Fprintf(os.Stderr, "missing %s cache, load from remote...\n", feed)
+	if result, err = fetch(); err != nil {
+		if expired == nil || s.MaxStale <= 0 {
+			return
+		}
+		if age := expired.Age(); age > s.MaxStale {
+			return result, errors.Wrapf(err, "cached %s data is %s old, over --max-stale %s", feed, age.Round(time.Minute), s.MaxStale)
+		}
+		if expired.Decode(&result) != nil {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "failed to load %s data from remote, use the cached copy: %v\n", feed, err)
+		s.track(feed, expired.FetchedAt, true)
+		return result, nil
+	}
+	if _, err := s.Cache.Store(feed, key, result); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to store cache:", err)
+	}
+	s.track(feed, time.Now(), false)
+	return result, nil
+}
+
+func (s *CachedSource) Advisor(ctx context.Context) (*models.AdvisorData, error) {
+	return cached(s, cache.AdvisorFeed, cache.AdvisorFeed, func() (*models.AdvisorData, error) {
+		return s.Source.Advisor(ctx)
+	})
+}
+
+func (s *CachedSource) Price(ctx context.Context) (*models.SpotPriceData, error) {
+	return cached(s, cache.PriceFeed, cache.PriceFeed, func() (*models.SpotPriceData, error) {
+		return s.Source.Price(ctx)
+	})
+}
+
+// Score the analyzer always asks for every instance type of a region in all
+// its AZs, so an entry per region and lifetimes holds the whole regional feed. Partial
+// results are passed through but never cached
+func (s *CachedSource) Score(ctx context.Context, req *ScoreRequest) ([]models.SpotinstScore, error) {
+	var partial []models.SpotinstScore
+	scores, err := cached(s, cache.ScoreFeed, cache.ScoreFeed+"-"+req.key(), func() ([]models.SpotinstScore, error) {
+		scores, err := s.Source.Score(ctx, req)
+		if err != nil {
+			partial = scores
+		}
+		return scores, err
+	})
+	if err != nil {
+		return filterScores(partial, req), err
+	}
+	return filterScores(scores, req), nil
+}
+
+// Zones an empty zone list is a failed discovery, it is never cached
+func (s *CachedSource) Zones(ctx context.Context, region string) ([]string, error) {
+	return cached(s, cache.ZonesFeed, cache.ZonesFeed+"-"+region, func() ([]string, error) {
+		azs, err := s.Source.Zones(ctx, region)
+		if err == nil && len(azs) == 0 {
+			err = errors.Errorf("no zones for region %s", region)
+		}
+		return azs, err
+	})
+}
@@ -0,0 +1,121 @@
+package aws
+
+import (
+	"context"
+	"github.com/bytedance/sonic"
+	"os"
+	"path/filepath"
+	"spotinfo/pkg/cache"
+	"spotinfo/pkg/models"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// failingSource fails every feed once fail is set
+type failingSource struct {
+	*MemorySource
+	fail bool
+}
+
+func (s *failingSource) Advisor(ctx context.Context) (*models.AdvisorData, error) {
+	if s.fail {
+		return nil, errors.New("unreachable")
+	}
+	return s.MemorySource.Advisor(ctx)
+}
+
+// emptyZonesSource discovers no zone
+type emptyZonesSource struct {
+	*MemorySource
+}
+
+func (s *emptyZonesSource) Zones(context.Context, string) ([]string, error) {
+	return []string{}, nil
+}
+
+func TestCachedSourceEmptyZones(t *testing.T) {
+	c := cache.New(t.TempDir(), map[string]time.Duration{cache.ZonesFeed: time.Hour})
+	cs := NewCachedSource(&emptyZonesSource{MemorySource: testSource(t)}, c, 0)
+	if _, err := cs.Zones(context.Background(), "us-east-1"); err == nil {
+		t.Error("expected a no zones error")
+	}
+	if _, err := c.Load(cache.ZonesFeed + "-us-east-1"); err == nil {
+		t.Error("empty zone list cached")
+	}
+}
+
+func TestCachedSourceStaleFallback(t *testing.T) {
+	ctx := context.Background()
+	c := cache.New(t.TempDir(), map[string]time.Duration{cache.AdvisorFeed: time.Hour})
+	src := &failingSource{MemorySource: testSource(t)}
+	if _, err := NewCachedSource(src, c, 0).Advisor(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// age the entry past its TTL
+	e, err := c.Load(cache.AdvisorFeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.FetchedAt = time.Now().Add(-3 * time.Hour)
+	var data models.AdvisorData
+	if err = e.Decode(&data); err != nil {
+		t.Fatal(err)
+	}
+	src.fail = true
+
+	tests := []struct {
+		name     string
+		maxStale time.Duration
+		wantErr  bool
+	}{
+		{name: "fallback disabled", maxStale: 0, wantErr: true},
+		{name: "too old", maxStale: time.Hour, wantErr: true},
+		{name: "fallback", maxStale: 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := c.Store(cache.AdvisorFeed, cache.AdvisorFeed, &data); err != nil {
+				t.Fatal(err)
+			}
+			backdate(t, c, e.FetchedAt)
+			cs := NewCachedSource(src, c, tt.maxStale)
+			got, err := cs.Advisor(ctx)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got.InstanceTypes) != len(data.InstanceTypes) {
+				t.Errorf("got %d instance types, want %d", len(got.InstanceTypes), len(data.InstanceTypes))
+			}
+			warnings := NewAnalyzer(cs).Warnings()
+			if len(warnings) != 1 || warnings[0] != "advisor data is 3 hours old" {
+				t.Errorf("unexpected warnings %v", warnings)
+			}
+		})
+	}
+}
+
+// backdate rewrite the advisor entry as fetched at the given time
+func backdate(t *testing.T, c *cache.Cache, fetchedAt time.Time) {
+	t.Helper()
+	e, err := c.Load(cache.AdvisorFeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.FetchedAt = fetchedAt
+	content, err := sonic.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(c.Dir, cache.AdvisorFeed+".json"), content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
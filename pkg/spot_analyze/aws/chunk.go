@@ -0,0 +1,21 @@
+package aws
+
+// chunk split s in consecutive chunks of size elements, the last one holding
+// the remainder. Chunks share the backing array of s
+func chunk[T any](s []T, size int) [][]T {
+	if size <= 0 {
+		size = len(s)
+	}
+	if len(s) == 0 {
+		return nil
+	}
+	chunks := make([][]T, 0, (len(s)+size-1)/size)
+	for start := 0; start < len(s); start += size {
+		end := start + size
+		if end > len(s) {
+			end = len(s)
+		}
+		chunks = append(chunks, s[start:end:end])
+	}
+	return chunks
+}
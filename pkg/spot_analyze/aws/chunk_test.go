@@ -0,0 +1,123 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"spotinfo/pkg/httpclient"
+	"sync"
+	"testing"
+	"testing/quick"
+
+	"github.com/bytedance/sonic"
+)
+
+// chunkCase random list length and chunk size
+type chunkCase struct {
+	N    int
+	Size int
+}
+
+func (chunkCase) Generate(r *rand.Rand, _ int) reflect.Value {
+	return reflect.ValueOf(chunkCase{N: r.Intn(300), Size: 1 + r.Intn(60)})
+}
+
+func TestChunk(t *testing.T) {
+	tests := []struct {
+		s    []int
+		size int
+		want [][]int
+	}{
+		{s: nil, size: 3, want: nil},
+		{s: []int{1, 2, 3}, size: 3, want: [][]int{{1, 2, 3}}},
+		{s: []int{1, 2, 3, 4}, size: 3, want: [][]int{{1, 2, 3}, {4}}},
+		{s: []int{1, 2, 3}, size: 5, want: [][]int{{1, 2, 3}}},
+		{s: []int{1, 2, 3}, size: 0, want: [][]int{{1, 2, 3}}},
+	}
+	for _, tt := range tests {
+		if got := chunk(tt.s, tt.size); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("chunk(%v, %d): got %v, want %v", tt.s, tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestChunkProperties(t *testing.T) {
+	property := func(c chunkCase) bool {
+		s := make([]int, c.N)
+		for i := range s {
+			s[i] = i
+		}
+		chunks := chunk(s, c.Size)
+		if len(chunks) != (c.N+c.Size-1)/c.Size {
+			return false
+		}
+		var joined []int
+		for i, ch := range chunks {
+			// only the last chunk may be short, none is empty
+			if len(ch) == 0 || len(ch) > c.Size || (i < len(chunks)-1 && len(ch) != c.Size) {
+				return false
+			}
+			joined = append(joined, ch...)
+		}
+		return len(joined) == c.N && (c.N == 0 || reflect.DeepEqual(joined, s))
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestSpotinstScoresBatches every instance type is requested exactly once,
+// whatever the list length and batch size
+func TestSpotinstScoresBatches(t *testing.T) {
+	var lock sync.Mutex
+	requested := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			InstanceTypes []string `json:"instanceTypes"`
+		}
+		content, _ := io.ReadAll(r.Body)
+		if err := sonic.Unmarshal(content, &body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		lock.Lock()
+		for _, instance := range body.InstanceTypes {
+			requested[instance]++
+		}
+		lock.Unlock()
+		fmt.Fprint(w, `{"items":[]}`)
+	}))
+	defer srv.Close()
+
+	property := func(c chunkCase) bool {
+		requested = make(map[string]int)
+		instances := make([]string, c.N)
+		for i := range instances {
+			instances[i] = fmt.Sprintf("t%d.large", i)
+		}
+		account := &SpotinstAccount{
+			Endpoint:         srv.URL,
+			Tokens:           StaticToken("token"),
+			Client:           httpclient.New(httpclient.Config{}),
+			ScoreBatchSize:   c.Size,
+			ScoreConcurrency: 4,
+		}
+		scs, err := getSpotinstScores(context.Background(), &ScoreRequest{Region: "us-east-1", Instances: instances, Azs: []string{"us-east-1a"}}, account)
+		if err != nil || scs == nil || len(requested) != c.N {
+			return false
+		}
+		for _, instance := range instances {
+			if requested[instance] != 1 {
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 50}); err != nil {
+		t.Error(err)
+	}
+}
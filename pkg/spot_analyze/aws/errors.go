@@ -0,0 +1,79 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// kinds of score batch failures
+const (
+	ScoreErrNetwork = "network"
+	ScoreErrAuth    = "auth"
+	ScoreErrStatus  = "status"
+	ScoreErrDecode  = "decode"
+)
+
+// ScoreError failure of one market score batch
+type ScoreError struct {
+	Batch      int
+	Kind       string
+	StatusCode int
+	Err        error
+}
+
+func (e *ScoreError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("score batch %d: %s error, code: %d: %v", e.Batch, e.Kind, e.StatusCode, e.Err)
+	}
+	return fmt.Sprintf("score batch %d: %s error: %v", e.Batch, e.Kind, e.Err)
+}
+
+func (e *ScoreError) Unwrap() error {
+	return e.Err
+}
+
+// ScoreErrors failures of the batches of a score request, the scores of the
+// other batches are still returned
+type ScoreErrors []*ScoreError
+
+func (e ScoreErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d score batches failed: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// countScoreErrors number of failed batches reported by err, 1 when err is not a ScoreErrors
+func countScoreErrors(err error) int {
+	var errs ScoreErrors
+	if errors.As(err, &errs) {
+		return len(errs)
+	}
+	return 1
+}
+
+// scoreErrorCollector collects the batch failures of concurrent score requests
+type scoreErrorCollector struct {
+	lock sync.Mutex
+	errs ScoreErrors
+}
+
+func (c *scoreErrorCollector) add(err *ScoreError) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.errs = append(c.errs, err)
+}
+
+// err nil when no batch failed
+func (c *scoreErrorCollector) err() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.errs) == 0 {
+		return nil
+	}
+	return c.errs
+}
@@ -0,0 +1,121 @@
+package aws
+
+import (
+	"spotinfo/pkg/instancetype"
+	"spotinfo/pkg/known"
+	"spotinfo/pkg/models"
+	"spotinfo/pkg/options"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// matchResources report whether the vCPU, memory and memory per vCPU of info are within the opts ranges,
+// zero maximums are unlimited
+func matchResources(info models.TypeInfo, opts *options.SpotinstOptions) bool {
+	if info.Cores < opts.MinCpu || (opts.MaxCpu != 0 && info.Cores > opts.MaxCpu) {
+		return false
+	}
+	ram := float64(info.RAM)
+	if ram < opts.MinMemory || (opts.MaxMemory != 0 && ram > opts.MaxMemory) {
+		return false
+	}
+	if opts.MinMemoryPerCpu == 0 && opts.MaxMemoryPerCpu == 0 {
+		return true
+	}
+	if info.Cores == 0 {
+		return false
+	}
+	ratio := ram / float64(info.Cores)
+	return ratio >= opts.MinMemoryPerCpu && (opts.MaxMemoryPerCpu == 0 || ratio <= opts.MaxMemoryPerCpu)
+}
+
+// interruptionLimit the max interruption percentage of --max-interruption, a band label of the
+// advisor data or a percentage. A percentage stands for the band holding it, e.g. 10 keeps 5-10%
+// whose max is 11. -1 when there is no limit
+func interruptionLimit(ranges []models.AdvisorRange, value string) (int, error) {
+	if value == "" {
+		return -1, nil
+	}
+	for _, r := range ranges {
+		if strings.EqualFold(r.Label, strings.TrimSpace(value)) {
+			return r.Max, nil
+		}
+	}
+	limit, err := options.ParsePercent(value)
+	if err != nil {
+		labels := make([]string, 0, len(ranges))
+		for _, r := range ranges {
+			labels = append(labels, r.Label)
+		}
+		return 0, errors.Errorf("invalid --max-interruption %q, must be a percentage or one of %s", value, strings.Join(labels, "|"))
+	}
+	band := -1
+	for _, r := range ranges {
+		if r.Max >= limit && (band < 0 || r.Max < band) {
+			band = r.Max
+		}
+	}
+	if band < 0 {
+		return limit, nil
+	}
+	return band, nil
+}
+
+// matchAdvice report whether advice passes the savings, price, interruption and score filters of opts.
+// azs are the AZs scored in score mode, maxInterruption is -1 for no limit
+func matchAdvice(advice *models.Advice, opts *options.SpotinstOptions, maxInterruption int, azs []string) bool {
+	if advice.Savings < opts.MinSavings {
+		return false
+	}
+	// instance types without pricing data can't be under the max price
+	if opts.MaxPrice != 0 && (advice.Price <= 0 || advice.Price > opts.MaxPrice) {
+		return false
+	}
+	if maxInterruption >= 0 && advice.Range.Max > maxInterruption {
+		return false
+	}
+	if opts.MinScore == 0 {
+		return true
+	}
+	if opts.ScoreMatch == known.AllZones {
+		for _, az := range azs {
+			scores, ok := advice.Score[az]
+			if !ok || len(scores) == 0 || scores.Min() < opts.MinScore {
+				return false
+			}
+		}
+		return len(azs) > 0
+	}
+	for _, scores := range advice.Score {
+		if len(scores) > 0 && scores.Min() >= opts.MinScore {
+			return true
+		}
+	}
+	return false
+}
+
+// matchType report whether the instance type metadata passes the family, generation, architecture
+// and burstable filters of opts. Families match the family letters or the class, e.g. m or m6g
+func matchType(info instancetype.Info, opts *options.SpotinstOptions) bool {
+	if len(opts.Families) > 0 {
+		matched := false
+		for _, family := range opts.Families {
+			family = strings.ToLower(strings.TrimSpace(family))
+			if info.Family != "" && (family == info.Family || family == info.Class()) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if opts.MinGeneration > 0 && info.Generation < opts.MinGeneration {
+		return false
+	}
+	if opts.Arch != "" && info.Arch != opts.Arch {
+		return false
+	}
+	return !(opts.ExcludeBurstable && info.Burstable)
+}
@@ -0,0 +1,169 @@
+package aws
+
+import (
+	"reflect"
+	"spotinfo/pkg/instancetype"
+	"spotinfo/pkg/known"
+	"spotinfo/pkg/models"
+	"spotinfo/pkg/options"
+	"testing"
+)
+
+func TestMatchResources(t *testing.T) {
+	large := models.TypeInfo{Cores: 2, RAM: 8}
+	tests := []struct {
+		name string
+		opts options.SpotinstOptions
+		want bool
+	}{
+		{name: "no filter", want: true},
+		{name: "min cpu", opts: options.SpotinstOptions{MinCpu: 4}},
+		{name: "cpu range", opts: options.SpotinstOptions{MinCpu: 2, MaxCpu: 2}, want: true},
+		{name: "max cpu", opts: options.SpotinstOptions{MaxCpu: 1}},
+		{name: "min memory", opts: options.SpotinstOptions{MinMemory: 8.5}},
+		{name: "memory range", opts: options.SpotinstOptions{MinMemory: 4, MaxMemory: 8}, want: true},
+		{name: "max memory", opts: options.SpotinstOptions{MaxMemory: 4}},
+		{name: "min ratio", opts: options.SpotinstOptions{MinMemoryPerCpu: 4}, want: true},
+		{name: "min ratio too high", opts: options.SpotinstOptions{MinMemoryPerCpu: 8}},
+		{name: "max ratio", opts: options.SpotinstOptions{MaxMemoryPerCpu: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchResources(large, &tt.opts); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterruptionLimit(t *testing.T) {
+	ranges := []models.AdvisorRange{{Label: "<5%", Max: 5}, {Label: "5-10%", Max: 11}, {Label: "10-15%", Max: 16},
+		{Label: "15-20%", Max: 22}, {Label: ">20%", Max: 100}}
+	tests := []struct {
+		value string
+		want  int
+		valid bool
+	}{
+		{value: "", want: -1, valid: true},
+		{value: "<5%", want: 5, valid: true},
+		{value: "5-10%", want: 11, valid: true},
+		// percentages keep the band holding them
+		{value: "5", want: 5, valid: true},
+		{value: "10", want: 11, valid: true},
+		{value: "15%", want: 16, valid: true},
+		{value: "21", want: 22, valid: true},
+		{value: "23", want: 100, valid: true},
+		{value: ">20%", want: 100, valid: true},
+		{value: "often"},
+	}
+	for _, tt := range tests {
+		got, err := interruptionLimit(ranges, tt.value)
+		if (err == nil) != tt.valid {
+			t.Errorf("%q: got error %v, want valid %v", tt.value, err, tt.valid)
+			continue
+		}
+		if tt.valid && got != tt.want {
+			t.Errorf("%q: got %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestMatchAdvice(t *testing.T) {
+	advice := &models.Advice{
+		Savings: 70,
+		Price:   0.04,
+		Range:   models.InterruptionRange{Label: "5-10%", Min: 6, Max: 11},
+		Score:   map[string]models.LifetimeScores{"us-east-1a": {1: 80, 4: 72}, "us-east-1b": {1: 40}},
+	}
+	azs := []string{"us-east-1a", "us-east-1b"}
+	tests := []struct {
+		name            string
+		opts            options.SpotinstOptions
+		maxInterruption int
+		want            bool
+	}{
+		{name: "no filter", maxInterruption: -1, want: true},
+		{name: "savings", opts: options.SpotinstOptions{MinSavings: 70}, maxInterruption: -1, want: true},
+		{name: "savings too low", opts: options.SpotinstOptions{MinSavings: 71}, maxInterruption: -1},
+		{name: "price", opts: options.SpotinstOptions{MaxPrice: 0.5}, maxInterruption: -1, want: true},
+		{name: "price too high", opts: options.SpotinstOptions{MaxPrice: 0.03}, maxInterruption: -1},
+		{name: "interruption", maxInterruption: 11, want: true},
+		{name: "interruption too high", maxInterruption: 5},
+		// the lowest score over the lifetimes counts
+		{name: "any zone", opts: options.SpotinstOptions{MinScore: 70}, maxInterruption: -1, want: true},
+		{name: "any zone too low", opts: options.SpotinstOptions{MinScore: 75}, maxInterruption: -1},
+		{name: "all zones", opts: options.SpotinstOptions{MinScore: 40, ScoreMatch: known.AllZones}, maxInterruption: -1, want: true},
+		{name: "all zones too low", opts: options.SpotinstOptions{MinScore: 50, ScoreMatch: known.AllZones}, maxInterruption: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchAdvice(advice, &tt.opts, tt.maxInterruption, azs); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+	// an unscored AZ fails all zones
+	opts := &options.SpotinstOptions{MinScore: 10, ScoreMatch: known.AllZones}
+	if matchAdvice(advice, opts, -1, append(azs, "us-east-1c")) {
+		t.Error("all zones: us-east-1c has no score")
+	}
+}
+
+func TestMatchType(t *testing.T) {
+	tests := []struct {
+		name string
+		opts options.SpotinstOptions
+		want []string
+	}{
+		{name: "no filter", want: []string{"m5.large", "m6g.large", "c7g.xlarge", "t4g.micro", "r6i.large"}},
+		{name: "families", opts: options.SpotinstOptions{Families: []string{"m", "C"}}, want: []string{"m5.large", "m6g.large", "c7g.xlarge"}},
+		{name: "class", opts: options.SpotinstOptions{Families: []string{"m6g"}}, want: []string{"m6g.large"}},
+		{name: "generation", opts: options.SpotinstOptions{MinGeneration: 6}, want: []string{"m6g.large", "c7g.xlarge", "r6i.large"}},
+		{name: "arch", opts: options.SpotinstOptions{Arch: instancetype.ARM64, ExcludeBurstable: true}, want: []string{"m6g.large", "c7g.xlarge"}},
+	}
+	names := []string{"m5.large", "m6g.large", "c7g.xlarge", "t4g.micro", "r6i.large"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, name := range names {
+				info, err := instancetype.Parse(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if matchType(info, &tt.opts) {
+					got = append(got, name)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+	// unknown names only pass without metadata filters
+	if matchType(instancetype.Info{}, &options.SpotinstOptions{MinGeneration: 1}) {
+		t.Error("unparsed type passed the generation filter")
+	}
+}
+
+func TestMaxInterruptionBoundaries(t *testing.T) {
+	ranges := []models.AdvisorRange{{Label: "<5%", Max: 5}, {Label: "5-10%", Max: 11}, {Label: "10-15%", Max: 16},
+		{Label: "15-20%", Max: 22}, {Label: ">20%", Max: 100}}
+	advice := &models.Advice{Range: models.InterruptionRange{Label: "5-10%", Min: 6, Max: 11}}
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{value: "5"},
+		{value: "10", want: true},
+		{value: "15", want: true},
+	}
+	for _, tt := range tests {
+		limit, err := interruptionLimit(ranges, tt.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := matchAdvice(advice, &options.SpotinstOptions{}, limit, nil); got != tt.want {
+			t.Errorf("--max-interruption %s: got %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
@@ -2,15 +2,16 @@ package aws
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
 	"github.com/bytedance/sonic"
-	"github.com/cloudwego/hertz/pkg/app/client"
 	"github.com/cloudwego/hertz/pkg/protocol"
 	"github.com/cloudwego/hertz/pkg/protocol/consts"
 	"os"
 	"regexp"
 	"sort"
+	"spotinfo/pkg/cache"
+	"spotinfo/pkg/httpclient"
+	"spotinfo/pkg/instancetype"
 	"spotinfo/pkg/known"
 	"spotinfo/pkg/models"
 	"spotinfo/pkg/options"
@@ -22,66 +23,11 @@ import (
 )
 
 var (
-	loadDataOnce sync.Once
-	// parsed json raw data
-	data *models.AdvisorData
 	// min ranges
 	minRange = map[int]int{5: 0, 11: 6, 16: 12, 22: 17, 100: 23} //nolint:gomnd
 )
 
-const (
-	// SortByRange sort by frequency of interruption
-	SortByRange = iota
-	// SortByInstance sort by instance type (lexicographical)
-	SortByInstance = iota
-	// SortBySavings sort by savings percentage
-	SortBySavings = iota
-	// SortByPrice sort by spot price
-	SortByPrice = iota
-	// SortByRegion sort by AWS region name
-	SortByRegion = iota
-	// SortByScore sort by Spotinst market score
-	SortByScore = iota
-)
-
-//---- public types
-
-// ByRange implements sort.Interface based on the Range.Min field
-type ByRange []models.Advice
-
-func (a ByRange) Len() int           { return len(a) }
-func (a ByRange) Less(i, j int) bool { return a[i].Range.Min < a[j].Range.Min }
-func (a ByRange) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
-
-// ByInstance implements sort.Interface based on the Instance field
-type ByInstance []models.Advice
-
-func (a ByInstance) Len() int           { return len(a) }
-func (a ByInstance) Less(i, j int) bool { return strings.Compare(a[i].Instance, a[j].Instance) == -1 }
-func (a ByInstance) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
-
-// BySavings implements sort.Interface based on the Savings field
-type BySavings []models.Advice
-
-func (a BySavings) Len() int           { return len(a) }
-func (a BySavings) Less(i, j int) bool { return a[i].Savings < a[j].Savings }
-func (a BySavings) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
-
-// ByPrice implements sort.Interface based on the Price field
-type ByPrice []models.Advice
-
-func (a ByPrice) Len() int           { return len(a) }
-func (a ByPrice) Less(i, j int) bool { return a[i].Price < a[j].Price }
-func (a ByPrice) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
-
-// ByRegion implements sort.Interface based on the Region field
-type ByRegion []models.Advice
-
-func (a ByRegion) Len() int           { return len(a) }
-func (a ByRegion) Less(i, j int) bool { return strings.Compare(a[i].Region, a[j].Region) == -1 }
-func (a ByRegion) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
-
-func dataLazyLoad(url string, timeout time.Duration) (result *models.AdvisorData, err error) {
+func dataLazyLoad(ctx context.Context, hc *httpclient.Client, url string, timeout time.Duration) (result *models.AdvisorData, err error) {
 	req, resp := protocol.AcquireRequest(), protocol.AcquireResponse()
 	defer func() {
 		protocol.ReleaseRequest(req)
@@ -89,11 +35,7 @@ func dataLazyLoad(url string, timeout time.Duration) (result *models.AdvisorData
 	}()
 	req.SetMethod(consts.MethodGet)
 	req.SetRequestURI(url)
-	hClient, _ := client.NewClient(client.WithTLSConfig(&tls.Config{
-		InsecureSkipVerify: true,
-	}))
-
-	err = hClient.DoTimeout(context.TODO(), req, resp, timeout)
+	err = hc.Do(ctx, req, resp, timeout)
 
 	if err != nil {
 		return
@@ -103,52 +45,184 @@ func dataLazyLoad(url string, timeout time.Duration) (result *models.AdvisorData
 		err = errors.New(fmt.Sprintf("url:%s code: %d, detail:%s", url, resp.StatusCode(), string(resp.Body())))
 		return
 	}
-	err = sonic.Unmarshal(resp.Body(), &result)
 
+	return parseAdvisorData(resp.Body())
+}
+
+// parseAdvisorData parse the spot-advisor-data.json payload
+func parseAdvisorData(body []byte) (result *models.AdvisorData, err error) {
+	if err = sonic.Unmarshal(body, &result); err != nil {
+		return nil, errors.Wrap(err, "failed to parse spot advisor data")
+	}
+	if result == nil || len(result.Regions) == 0 {
+		return nil, errors.New("empty spot advisor data")
+	}
 	return result, nil
 }
 
-// GetSpotSavings get spot saving advices
+// Analyzer builds spot advices from the feeds of a Source, every feed is
+// loaded at most once per analyzer
+type Analyzer struct {
+	source Source
+
+	loadDataOnce sync.Once
+	data         *models.AdvisorData
+	dataErr      error
+
+	loadPriceOnce sync.Once
+	spotPrice     *models.SpotPriceData
+	priceErr      error
+
+	// guards the per region zones and scores
+	scoreLock sync.Mutex
+	zones     map[string][]string
+	// spotScores scores per product
+	spotScores map[string]*spotScoreData
+	scoreErrs  map[string]error
+
+	warnings []string
+}
+
+// NewAnalyzer create an analyzer reading its feeds from src
+func NewAnalyzer(src Source) *Analyzer {
+	return &Analyzer{
+		source:     src,
+		zones:      make(map[string][]string),
+		spotScores: make(map[string]*spotScoreData),
+		scoreErrs:  make(map[string]error),
+	}
+}
+
+// GetSpotSavings get spot saving advices, feeds are read from the source selected by opts
 func GetSpotSavings(ctx context.Context, opts *options.SpotinstOptions) ([]models.Advice, error) {
-	var err error
-	loadDataOnce.Do(func() {
-		const timeout = 10
-		// 先从本地加载数据
-		infoJsonPath := "/tmp/info.json"
-		if f, err := os.Stat(infoJsonPath); err == nil && f.Size() >= 100 {
-			// 判断文件时间
-			if time.Now().Sub(f.ModTime()) < 24*time.Hour {
-				// 从文件中加载数据
-				fmt.Println("load info data from cache...")
-				content, _ := os.ReadFile(infoJsonPath)
-				err = sonic.Unmarshal(content, &data)
-				return
+	src, err := NewSource(opts)
+	if err != nil {
+		return nil, err
+	}
+	return NewAnalyzer(src).GetSpotSavings(ctx, opts)
+}
 
+// Warnings notes about the data the advices were built from, e.g. stale feeds
+func (a *Analyzer) Warnings() []string {
+	warnings := append([]string(nil), a.warnings...)
+	if r, ok := a.source.(StatusReporter); ok {
+		for _, st := range r.FeedStatus() {
+			if st.Stale {
+				warnings = append(warnings, fmt.Sprintf("%s data is %d hours old", st.Feed, int(time.Since(st.FetchedAt).Hours())))
 			}
-		} else {
-			os.Create(infoJsonPath)
 		}
-		fmt.Println("missing info cache, load from remote...")
-		data, err = dataLazyLoad(known.SpotAdvisorJSONURL, timeout*time.Second)
-		contentByte, _ := sonic.Marshal(data)
-		os.WriteFile(infoJsonPath, contentByte, 0644)
+	}
+	return warnings
+}
+
+// Zones the AZs of every region scored by GetSpotSavings, empty in normal mode
+func (a *Analyzer) Zones() map[string][]string {
+	a.scoreLock.Lock()
+	defer a.scoreLock.Unlock()
+	zones := make(map[string][]string, len(a.zones))
+	for region, azs := range a.zones {
+		zones[region] = append([]string(nil), azs...)
+	}
+	return zones
+}
+
+// FeedStatus when the feeds the advices were built from were fetched, empty
+// when the source doesn't know
+func (a *Analyzer) FeedStatus() []models.FeedStatus {
+	if r, ok := a.source.(StatusReporter); ok {
+		return r.FeedStatus()
+	}
+	return nil
+}
+
+// loadData load the advisor feed from the analyzer source once
+func (a *Analyzer) loadData(ctx context.Context) error {
+	a.loadDataOnce.Do(func() {
+		a.data, a.dataErr = a.source.Advisor(ctx)
 	})
+	return errors.Wrap(a.dataErr, "failed to load spot data")
+}
 
+// Load load the given feeds ahead of any analysis
+func (a *Analyzer) Load(ctx context.Context, feeds ...string) error {
+	for _, feed := range feeds {
+		var err error
+		switch feed {
+		case cache.AdvisorFeed:
+			err = a.loadData(ctx)
+		case cache.PriceFeed:
+			err = a.loadPrice(ctx)
+		case cache.ScoreFeed:
+			if err = a.loadData(ctx); err != nil {
+				break
+			}
+			for _, region := range a.regions() {
+				if err = a.loadScores(ctx, &ScoreRequest{Region: region, Azs: a.getZones(ctx, region)}); err != nil {
+					break
+				}
+			}
+		default:
+			err = errors.Errorf("unknown feed %q, must be one of %s", feed, strings.Join(cache.Feeds, "|"))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// regions all regions of the advisor data
+func (a *Analyzer) regions() []string {
+	regions := make([]string, 0, len(a.data.Regions))
+	for k := range a.data.Regions {
+		regions = append(regions, k)
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+// GetSpotSavings get spot saving advices
+func (a *Analyzer) GetSpotSavings(ctx context.Context, opts *options.SpotinstOptions) ([]models.Advice, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	sortKeys, err := ParseSort(opts.Sort, opts.Order)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.loadData(ctx); err != nil {
+		return nil, err
+	}
+	data := a.data
+	if err := a.loadPrice(ctx); err != nil {
+		return nil, err
+	}
+	maxInterruption, err := interruptionLimit(data.Ranges, opts.MaxInterruption)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to load spot data")
+		return nil, err
+	}
+	typeRe, err := regexp.Compile(opts.Type)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to match instance type")
+	}
+	instanceOs := strings.ToLower(opts.Os)
+	product, ok := known.ScoreProducts[instanceOs]
+	if !ok {
+		return nil, errors.Errorf("invalid instance OS %q, must be %s|%s|%s|%s", opts.Os,
+			known.LinuxOS, known.WindowsOS, known.RHELOS, known.SUSEOS)
 	}
+
 	var regions = opts.Region
 	// special case: "all" regions (slice with single element)
 	if len(opts.Region) == 1 && opts.Region[0] == "all" {
 		// replace regions with all available regions
-		regions = make([]string, 0, len(data.Regions))
-		for k := range data.Regions {
-			regions = append(regions, k)
-		}
+		regions = a.regions()
 	}
 
 	// get advices for specified regions
 	var result []models.Advice
+	// instance/AZ pairs without score and failed score batches
+	var missingScores, failedBatches int
 
 	for _, region := range regions {
 		r, ok := data.Regions[region]
@@ -156,42 +230,47 @@ func GetSpotSavings(ctx context.Context, opts *options.SpotinstOptions) ([]model
 			return nil, errors.Errorf("no spot price for region %s", region)
 		}
 
-		var spotInfos map[string]models.SpotInfo
-		if strings.EqualFold("windows", opts.Os) {
+		// the advisor feed only covers windows and linux, rhel and suse run on the linux offers
+		spotInfos := r.Linux
+		if instanceOs == known.WindowsOS {
 			spotInfos = r.Windows
-		} else if strings.EqualFold("linux", opts.Os) {
-			spotInfos = r.Linux
-		} else {
-			return nil, errors.New("invalid instance OS, must be windows/linux")
+		}
+		// get spotinst score details
+		var azs []string
+		if opts.Mode == known.ScoreMode {
+			azs = a.getZones(ctx, region)
+			// partial failures keep the scores fetched
+			if err := a.loadScores(ctx, &ScoreRequest{Region: region, Azs: azs, Lifetimes: opts.Lifetimes, Product: product}); err != nil {
+				if opts.FailOnPartial {
+					return nil, err
+				}
+				fmt.Fprintln(os.Stderr, "get spot instance score failed", err)
+				failedBatches += countScoreErrors(err)
+			}
 		}
 		// construct advices result
 		for instance, adv := range spotInfos {
 			// match instance type name
-			matched, err := regexp.MatchString(opts.Type, instance)
-			if err != nil {
-				return nil, errors.Wrap(err, "failed to match instance type")
+			if !typeRe.MatchString(instance) { // skip not matched
+				continue
 			}
-			if !matched { // skip not matched
+			// names that don't parse only pass when no metadata filter is set
+			typeInfo, _ := instancetype.Parse(instance)
+			if !matchType(typeInfo, opts) {
 				continue
 			}
-			// filter by min vCPU and memory
-			info := data.InstanceTypes[instance]
-			if (opts.MaxCpu != 0 && info.Cores > opts.MaxCpu) || (opts.MaxMemory != 0 && info.RAM > float32(opts.MaxMemory)) {
+			// filter by vCPU and memory ranges
+			info := models.TypeInfo(data.InstanceTypes[instance])
+			if !matchResources(info, opts) {
 				continue
 			}
 			// get price details
-			spotPriceDatas, err := getSpotInstancePrice(instance, region, opts.Os)
-
-			var spotScoreMaps = make(map[string]int)
-			// get spotinst score details
-
-			if azs, ok := known.AvailablespotinstAzs[region]; ok && opts.Mode == known.ScoreMode {
-				for _, az := range azs {
-					score, err := getSpotInstanceScore(ctx, instance, az, data)
-					if err != nil {
-						fmt.Println("get spot instance score failed", err)
-						continue
-					}
+			// instance types without pricing data keep a zero price
+			spotPriceDatas, _ := a.getSpotInstancePrice(ctx, instance, region, opts.Os)
+
+			var spotScoreMaps = make(map[string]models.LifetimeScores)
+			for _, az := range azs {
+				if score, ok := a.getSpotInstanceScore(product, instance, az); ok {
 					spotScoreMaps[az] = score
 				}
 			}
@@ -202,41 +281,34 @@ func GetSpotSavings(ctx context.Context, opts *options.SpotinstOptions) ([]model
 				Max:   data.Ranges[adv.Range].Max,
 				Min:   minRange[data.Ranges[adv.Range].Max],
 			}
-			result = append(result, models.Advice{
+			advice := models.Advice{
 				Region:   region,
 				Instance: instance,
 				Range:    rng,
 				Savings:  adv.Savings,
 				Score:    spotScoreMaps,
-				Info:     models.TypeInfo(info),
+				Info:     info,
+				Type:     typeInfo,
 				Price:    spotPriceDatas,
-			})
+			}
+			if !matchAdvice(&advice, opts, maxInterruption, azs) {
+				continue
+			}
+			// only the advices printed count
+			missingScores += len(azs) - len(spotScoreMaps)
+			result = append(result, advice)
 		}
 	}
 
-	// sort results by - range (default)
-	var data sort.Interface
-
-	switch opts.Sort {
-	case "rage":
-		data = ByRange(result)
-	case "instance":
-		data = ByInstance(result)
-	case "saving":
-		data = BySavings(result)
-	case "price":
-		data = ByPrice(result)
-	case "region":
-		data = ByRegion(result)
-	default:
-		data = ByRange(result)
-	}
-
-	if opts.Order == "desc" {
-		data = sort.Reverse(data)
+	if missingScores > 0 || failedBatches > 0 {
+		warning := fmt.Sprintf("%d instance/AZ pairs are missing scores", missingScores)
+		if failedBatches > 0 {
+			warning += fmt.Sprintf(", %d score batches failed", failedBatches)
+		}
+		a.warnings = append(a.warnings, warning)
 	}
 
-	sort.Sort(data)
+	SortAdvices(result, sortKeys)
 
 	return result, nil
 }
@@ -1 +1,269 @@
 package aws
+
+import (
+	"context"
+	"reflect"
+	"spotinfo/pkg/known"
+	"spotinfo/pkg/models"
+	"spotinfo/pkg/options"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/pkg/errors"
+)
+
+const testAdvisorData = `{
+  "ranges": [
+    {"index": 0, "label": "<5%", "dots": 0, "max": 5},
+    {"index": 1, "label": "5-10%", "dots": 1, "max": 11}
+  ],
+  "instance_types": {
+    "m5.large": {"emr": true, "cores": 2, "ram_gb": 8},
+    "m5.xlarge": {"emr": true, "cores": 4, "ram_gb": 16},
+    "c5.large": {"emr": false, "cores": 2, "ram_gb": 4}
+  },
+  "spot_advisor": {
+    "us-east-1": {
+      "Linux": {
+        "m5.large": {"s": 70, "r": 0},
+        "m5.xlarge": {"s": 60, "r": 1},
+        "c5.large": {"s": 50, "r": 0}
+      },
+      "Windows": {
+        "m5.large": {"s": 40, "r": 1}
+      }
+    }
+  }
+}`
+
+var (
+	linuxProduct   = known.ScoreProducts[known.LinuxOS]
+	windowsProduct = known.ScoreProducts[known.WindowsOS]
+)
+
+func testSource(t *testing.T) *MemorySource {
+	t.Helper()
+	var data models.AdvisorData
+	if err := sonic.UnmarshalString(testAdvisorData, &data); err != nil {
+		t.Fatal(err)
+	}
+	return &MemorySource{
+		AdvisorData: &data,
+		PriceData: &models.SpotPriceData{Region: map[string]models.RegionPrice{
+			"us-east-1": {Instance: map[string]models.InstancePrice{
+				"m5.large":  {Linux: 0.04, Windows: 0.08},
+				"m5.xlarge": {Linux: 0.08, Windows: 0.16},
+				"c5.large":  {Linux: 0.03, Windows: 0.07},
+			}},
+		}},
+		Scores: []models.SpotinstScore{
+			{Az: "us-east-1a", InstanceType: "m5.large", Score: 80, Lifetime: 1, Product: linuxProduct},
+			{Az: "us-east-1b", InstanceType: "m5.large", Score: 40, Lifetime: 1, Product: linuxProduct},
+			{Az: "us-east-1a", InstanceType: "m5.xlarge", Score: 60, Lifetime: 1, Product: linuxProduct},
+			{Az: "us-east-1a", InstanceType: "m5.large", Score: 70, Lifetime: 4, Product: linuxProduct},
+			{Az: "us-east-1a", InstanceType: "m5.large", Score: 30, Lifetime: 1, Product: windowsProduct},
+		},
+		ZoneData: map[string][]string{"us-east-1": {"us-east-1a", "us-east-1b"}},
+	}
+}
+
+func TestAnalyzerGetSpotSavings(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      options.SpotinstOptions
+		instances []string
+	}{
+		{
+			name:      "all linux sorted by savings",
+			opts:      options.SpotinstOptions{Region: []string{"us-east-1"}, Os: "linux", Sort: "saving", Order: "desc", Mode: known.NormalMode},
+			instances: []string{"m5.large", "m5.xlarge", "c5.large"},
+		},
+		{
+			name:      "instance type regexp",
+			opts:      options.SpotinstOptions{Region: []string{"all"}, Os: "linux", Type: "^m5", Sort: "saving", Order: "asc", Mode: known.NormalMode},
+			instances: []string{"m5.xlarge", "m5.large"},
+		},
+		{
+			name:      "windows",
+			opts:      options.SpotinstOptions{Region: []string{"us-east-1"}, Os: "Windows", Mode: known.NormalMode},
+			instances: []string{"m5.large"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			advices, err := NewAnalyzer(testSource(t)).GetSpotSavings(context.Background(), &tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(advices) != len(tt.instances) {
+				t.Fatalf("got %d advices, want %d", len(advices), len(tt.instances))
+			}
+			for i, instance := range tt.instances {
+				if advices[i].Instance != instance {
+					t.Errorf("advice %d: got %s, want %s", i, advices[i].Instance, instance)
+				}
+				if advices[i].Price == 0 {
+					t.Errorf("advice %d: missing price", i)
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyzerScores(t *testing.T) {
+	opts := &options.SpotinstOptions{Region: []string{"us-east-1"}, Os: "linux", Type: `m5\.large`, Mode: known.ScoreMode}
+	advices, err := NewAnalyzer(testSource(t)).GetSpotSavings(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(advices) != 1 {
+		t.Fatalf("got %d advices, want 1", len(advices))
+	}
+	if got := advices[0].Score["us-east-1a"][1]; got != 80 {
+		t.Errorf("us-east-1a score: got %d, want 80", got)
+	}
+	if got := advices[0].Score["us-east-1b"][1]; got != 40 {
+		t.Errorf("us-east-1b score: got %d, want 40", got)
+	}
+}
+
+func TestAnalyzerLifetimes(t *testing.T) {
+	opts := &options.SpotinstOptions{Region: []string{"us-east-1"}, Os: "linux", Type: `m5\.large`, Mode: known.ScoreMode,
+		Lifetimes: []int{1, 4}}
+	advices, err := NewAnalyzer(testSource(t)).GetSpotSavings(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(advices) != 1 {
+		t.Fatalf("got %d advices, want 1", len(advices))
+	}
+	want := models.LifetimeScores{1: 80, 4: 70}
+	if got := advices[0].Score["us-east-1a"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("us-east-1a scores: got %v, want %v", got, want)
+	}
+	if got := advices[0].Score["us-east-1a"].Min(); got != 70 {
+		t.Errorf("min score: got %d, want 70", got)
+	}
+}
+
+func TestAnalyzerProducts(t *testing.T) {
+	tests := []struct {
+		os     string
+		scores map[string]models.LifetimeScores
+	}{
+		{os: "Linux", scores: map[string]models.LifetimeScores{"us-east-1a": {1: 80}, "us-east-1b": {1: 40}}},
+		{os: "windows", scores: map[string]models.LifetimeScores{"us-east-1a": {1: 30}}},
+		// the rhel market isn't scored in the test source
+		{os: "rhel", scores: map[string]models.LifetimeScores{}},
+	}
+	// one analyzer serving every OS must not mix their scores
+	a := NewAnalyzer(testSource(t))
+	for _, tt := range tests {
+		t.Run(tt.os, func(t *testing.T) {
+			opts := &options.SpotinstOptions{Region: []string{"us-east-1"}, Os: tt.os, Type: `m5\.large`, Mode: known.ScoreMode}
+			advices, err := a.GetSpotSavings(context.Background(), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(advices) != 1 {
+				t.Fatalf("got %d advices, want 1", len(advices))
+			}
+			if !reflect.DeepEqual(advices[0].Score, tt.scores) {
+				t.Errorf("got %v, want %v", advices[0].Score, tt.scores)
+			}
+		})
+	}
+	opts := &options.SpotinstOptions{Region: []string{"us-east-1"}, Os: "macos", Mode: known.ScoreMode}
+	if _, err := a.GetSpotSavings(context.Background(), opts); err == nil {
+		t.Error("expected an invalid OS error")
+	}
+}
+
+func TestScoreRequestKey(t *testing.T) {
+	tests := []struct {
+		req  ScoreRequest
+		want string
+	}{
+		{req: ScoreRequest{Region: "us-east-1"}, want: "us-east-1-linux-unix-amazon-vpc-1h"},
+		{req: ScoreRequest{Region: "us-east-1", Product: windowsProduct, Lifetimes: []int{1, 24}}, want: "us-east-1-windows-amazon-vpc-1h-24h"},
+		{req: ScoreRequest{Region: "eu-west-1", Product: known.ScoreProducts[known.RHELOS]}, want: "eu-west-1-red-hat-enterprise-linux-amazon-vpc-1h"},
+	}
+	for _, tt := range tests {
+		if got := tt.req.key(); got != tt.want {
+			t.Errorf("got %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzerStaticZones(t *testing.T) {
+	src := testSource(t)
+	src.ZoneData = nil
+	opts := &options.SpotinstOptions{Region: []string{"us-east-1"}, Os: "linux", Type: `m5\.large`, Mode: known.ScoreMode}
+	analyzer := NewAnalyzer(src)
+	advices, err := analyzer.GetSpotSavings(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(advices) != 1 || len(advices[0].Score) != 2 {
+		t.Fatalf("unexpected advices %+v", advices)
+	}
+	// 2 of the 5 static zones have scores
+	warnings := analyzer.Warnings()
+	if len(warnings) != 2 || warnings[1] != "3 instance/AZ pairs are missing scores" {
+		t.Errorf("unexpected warnings %v", warnings)
+	}
+	// filtered out advices don't count
+	opts.MinScore = 100
+	analyzer = NewAnalyzer(src)
+	if advices, err = analyzer.GetSpotSavings(context.Background(), opts); err != nil || len(advices) != 0 {
+		t.Fatalf("unexpected advices %+v, error %v", advices, err)
+	}
+	if warnings = analyzer.Warnings(); len(warnings) != 1 {
+		t.Errorf("unexpected warnings %v", warnings)
+	}
+}
+
+// partialSource fails the second score batch
+type partialSource struct {
+	*MemorySource
+}
+
+func (s *partialSource) Score(ctx context.Context, req *ScoreRequest) ([]models.SpotinstScore, error) {
+	scores, _ := s.MemorySource.Score(ctx, req)
+	return scores[:1], ScoreErrors{{Batch: 1, Kind: ScoreErrStatus, StatusCode: 500, Err: errors.New("boom")}}
+}
+
+func TestAnalyzerPartialScores(t *testing.T) {
+	opts := &options.SpotinstOptions{Region: []string{"us-east-1"}, Os: "linux", Type: `m5\.large`, Mode: known.ScoreMode}
+	analyzer := NewAnalyzer(&partialSource{testSource(t)})
+	advices, err := analyzer.GetSpotSavings(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(advices) != 1 || len(advices[0].Score) != 1 {
+		t.Fatalf("unexpected advices %+v", advices)
+	}
+	warnings := analyzer.Warnings()
+	if len(warnings) != 1 || warnings[0] != "1 instance/AZ pairs are missing scores, 1 score batches failed" {
+		t.Errorf("unexpected warnings %v", warnings)
+	}
+
+	opts.FailOnPartial = true
+	_, err = NewAnalyzer(&partialSource{testSource(t)}).GetSpotSavings(context.Background(), opts)
+	var errs ScoreErrors
+	if !errors.As(err, &errs) || errs[0].StatusCode != 500 {
+		t.Errorf("got %v, want ScoreErrors", err)
+	}
+}
+
+func TestAnalyzerSourceErrors(t *testing.T) {
+	opts := &options.SpotinstOptions{Region: []string{"us-east-1"}, Os: "linux"}
+	if _, err := NewAnalyzer(&MemorySource{}).GetSpotSavings(context.Background(), opts); err == nil {
+		t.Error("expected an error without advisor data")
+	}
+	src := testSource(t)
+	src.PriceData = nil
+	if _, err := NewAnalyzer(src).GetSpotSavings(context.Background(), opts); err == nil {
+		t.Error("expected an error without pricing data")
+	}
+}
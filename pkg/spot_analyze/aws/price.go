@@ -2,26 +2,20 @@ package aws
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
 	"github.com/bytedance/sonic"
-	"github.com/cloudwego/hertz/pkg/app/client"
 	"github.com/cloudwego/hertz/pkg/protocol"
 	"github.com/cloudwego/hertz/pkg/protocol/consts"
-	"os"
-	"spotinfo/pkg/known"
+	"spotinfo/pkg/httpclient"
+	"spotinfo/pkg/models"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/pkg/errors"
 )
 
 var (
-	loadPriceOnce sync.Once
-	// spot pricing data
-	spotPrice *spotPriceData
 	// aws region map: map between non-standard codes in spot pricing JS and AWS region code
 	awsSpotPricingRegions = map[string]string{
 		"us-east":    "us-east-1",
@@ -62,19 +56,7 @@ type rawPriceData struct {
 	} `json:"config"`
 }
 
-type instancePrice struct {
-	Linux   float64 `json:"linux"`
-	Windows float64 `json:"windows"`
-}
-type regionPrice struct {
-	Instance map[string]instancePrice `json:"instance"`
-}
-type spotPriceData struct {
-	Region map[string]regionPrice `json:"region"`
-}
-
-func pricingLazyLoad(url string, timeout time.Duration) (result *rawPriceData, err error) {
-	result = &rawPriceData{}
+func pricingLazyLoad(ctx context.Context, hc *httpclient.Client, url string, timeout time.Duration) (result *rawPriceData, err error) {
 	req, resp := protocol.AcquireRequest(), protocol.AcquireResponse()
 	defer func() {
 		protocol.ReleaseRequest(req)
@@ -82,11 +64,7 @@ func pricingLazyLoad(url string, timeout time.Duration) (result *rawPriceData, e
 	}()
 	req.SetMethod(consts.MethodGet)
 	req.SetRequestURI(url)
-	hClient, _ := client.NewClient(client.WithTLSConfig(&tls.Config{
-		InsecureSkipVerify: true,
-	}))
-
-	err = hClient.DoTimeout(context.TODO(), req, resp, timeout)
+	err = hc.Do(ctx, req, resp, timeout)
 
 	if err != nil {
 		return
@@ -95,13 +73,20 @@ func pricingLazyLoad(url string, timeout time.Duration) (result *rawPriceData, e
 		err = errors.New(fmt.Sprintf("url:%s code: %d, detail:%s", url, resp.StatusCode(), string(resp.Body())))
 		return
 	}
-	bodyString := string(resp.Body())
+
+	return parseRawPriceData(resp.Body())
+}
+
+// parseRawPriceData parse the spot.js JSONP payload
+func parseRawPriceData(body []byte) (result *rawPriceData, err error) {
+	result = &rawPriceData{}
+	bodyString := strings.TrimSpace(string(body))
 
 	bodyString = strings.TrimPrefix(bodyString, responsePrefix)
 	bodyString = strings.TrimSuffix(bodyString, responseSuffix)
 	err = sonic.UnmarshalString(bodyString, &result)
 	if err != nil {
-		return
+		return nil, errors.Wrap(err, "failed to parse spot pricing data")
 	}
 
 	for index, r := range result.Config.Regions {
@@ -113,18 +98,18 @@ func pricingLazyLoad(url string, timeout time.Duration) (result *rawPriceData, e
 	return
 }
 
-func convertRawData(raw *rawPriceData) *spotPriceData {
+func convertRawData(raw *rawPriceData) *models.SpotPriceData {
 	// fill priceData from rawPriceData
-	var pricing spotPriceData
-	pricing.Region = make(map[string]regionPrice)
+	var pricing models.SpotPriceData
+	pricing.Region = make(map[string]models.RegionPrice)
 
 	for _, region := range raw.Config.Regions {
-		var rp regionPrice
-		rp.Instance = make(map[string]instancePrice)
+		var rp models.RegionPrice
+		rp.Instance = make(map[string]models.InstancePrice)
 
 		for _, it := range region.InstanceTypes {
 			for _, size := range it.Sizes {
-				var ip instancePrice
+				var ip models.InstancePrice
 
 				for _, os := range size.ValueColumns {
 					price, err := strconv.ParseFloat(os.Prices.USD, 64)
@@ -149,46 +134,20 @@ func convertRawData(raw *rawPriceData) *spotPriceData {
 	return &pricing
 }
 
-func getSpotInstancePrice(instance, region, instanceOs string) (float64, error) {
-	var (
-		err  error
-		data *rawPriceData
-	)
-	loadPriceOnce.Do(func() {
-		// 先从本地加载数据
-		awsPriceJsonPath := "/tmp/price.json"
-		if f, err := os.Stat(awsPriceJsonPath); err == nil && f.Size() >= 100 {
-			// 判断文件时间
-			if time.Now().Sub(f.ModTime()) < 24*time.Hour {
-				// 从文件中加载数据
-				fmt.Println("load price data from cache...")
-				content, _ := os.ReadFile(awsPriceJsonPath)
-				err = sonic.Unmarshal(content, &spotPrice)
-				return
-			}
-		} else {
-			os.Create(awsPriceJsonPath)
-		}
-		fmt.Println("missing price cache, load from remote...")
-		data, err = pricingLazyLoad(known.SpotPriceJsURL, 1*time.Minute)
-		if err != nil {
-			fmt.Println("pricingLazyLoad failed, detail: ", err.Error())
-			return
-		}
-		spotPrice = convertRawData(data)
-
-		contentByte, err := sonic.Marshal(spotPrice)
-		if err != nil {
-			fmt.Println(err)
-		}
-		os.WriteFile(awsPriceJsonPath, contentByte, 0644)
+// loadPrice load the pricing feed from the analyzer source once
+func (a *Analyzer) loadPrice(ctx context.Context) error {
+	a.loadPriceOnce.Do(func() {
+		a.spotPrice, a.priceErr = a.source.Price(ctx)
 	})
+	return errors.Wrap(a.priceErr, "failed to load spot instance pricing")
+}
 
-	if err != nil {
-		return 0, errors.Wrap(err, "failed to load spot instance pricing")
+func (a *Analyzer) getSpotInstancePrice(ctx context.Context, instance, region, instanceOs string) (float64, error) {
+	if err := a.loadPrice(ctx); err != nil {
+		return 0, err
 	}
 
-	rp, ok := spotPrice.Region[region]
+	rp, ok := a.spotPrice.Region[region]
 	if !ok {
 		return 0, errors.Errorf("no pricind fata for region: %v", region)
 	}
@@ -198,7 +157,7 @@ func getSpotInstancePrice(instance, region, instanceOs string) (float64, error)
 		return 0, errors.Errorf("no pricind fata for instance: %v", instance)
 	}
 
-	if instanceOs == "windows" {
+	if strings.EqualFold(instanceOs, "windows") {
 		return price.Windows, nil
 	}
 
@@ -1 +1,36 @@
 package aws
+
+import (
+	"testing"
+)
+
+const testPriceJs = `callback({"vers":0.01,"config":{"rate":"perhr","valueColumns":["linux","mswin"],"currencies":["USD"],
+"regions":[{"region":"us-east","instanceTypes":[{"type":"generalCurrentGen","sizes":[
+{"size":"m5.large","valueColumns":[{"name":"linux","prices":{"USD":"0.0400"}},{"name":"mswin","prices":{"USD":"0.0800"}}]},
+{"size":"m5.xlarge","valueColumns":[{"name":"linux","prices":{"USD":"N/A*"}},{"name":"mswin","prices":{"USD":"0.1600"}}]}
+]}]}]}});
+`
+
+func TestParseRawPriceData(t *testing.T) {
+	raw, err := parseRawPriceData([]byte(testPriceJs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pricing := convertRawData(raw)
+	rp, ok := pricing.Region["us-east-1"]
+	if !ok {
+		t.Fatal("region us-east not mapped to us-east-1")
+	}
+	if got := rp.Instance["m5.large"]; got.Linux != 0.04 || got.Windows != 0.08 {
+		t.Errorf("m5.large: got %+v", got)
+	}
+	if got := rp.Instance["m5.xlarge"]; got.Linux != 0 || got.Windows != 0.16 {
+		t.Errorf("m5.xlarge: got %+v", got)
+	}
+}
+
+func TestParseRawPriceDataInvalid(t *testing.T) {
+	if _, err := parseRawPriceData([]byte("callback(nope);")); err == nil {
+		t.Error("expected a parse error")
+	}
+}
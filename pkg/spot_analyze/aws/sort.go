@@ -0,0 +1,218 @@
+package aws
+
+import (
+	"sort"
+	"spotinfo/pkg/models"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// sort keys accepted by --sort
+const (
+	// SortByRange sort by frequency of interruption
+	SortByRange = "interruption"
+	// SortByInstance sort by instance type (lexicographical)
+	SortByInstance = "type"
+	// SortBySavings sort by savings percentage
+	SortBySavings = "savings"
+	// SortByPrice sort by spot price
+	SortByPrice = "price"
+	// SortByRegion sort by AWS region name
+	SortByRegion = "region"
+	// SortByScore sort by Spotinst market score, the lowest score over the lifetimes
+	SortByScore = "score"
+	// SortByZone sort by availability zone, only meaningful per AZ
+	SortByZone = "az"
+	// SortByZonePrice sort by the spot price in the AZ, the region price when the AZ has none
+	SortByZonePrice = "az-price"
+	// SortByPricePerCPU sort by spot price per vCPU
+	SortByPricePerCPU = "price-per-vcpu"
+	// SortByPricePerGiB sort by spot price per GiB of memory
+	SortByPricePerGiB = "price-per-gib"
+)
+
+// SortKeys every sort key
+var SortKeys = []string{SortByRange, SortByInstance, SortBySavings, SortByPrice, SortByRegion, SortByScore,
+	SortByZone, SortByZonePrice, SortByPricePerCPU, SortByPricePerGiB}
+
+// sortAliases former and short sort key names
+var sortAliases = map[string]string{
+	"rage":     SortByRange,
+	"range":    SortByRange,
+	"instance": SortByInstance,
+	"saving":   SortBySavings,
+}
+
+// sort orders
+const (
+	Asc  = "asc"
+	Desc = "desc"
+)
+
+// SortKey one key of a multi-key sort
+type SortKey struct {
+	Key  string
+	Desc bool
+}
+
+// ParseSort parse a sort spec like score:desc,price:asc, keys without an order use order
+func ParseSort(spec, order string) ([]SortKey, error) {
+	defaultDesc, err := parseOrder(order)
+	if err != nil {
+		return nil, err
+	}
+	var keys []SortKey
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(strings.ToLower(field))
+		if field == "" {
+			continue
+		}
+		name, dir, hasDir := strings.Cut(field, ":")
+		if alias, ok := sortAliases[name]; ok {
+			name = alias
+		}
+		if !containsKey(SortKeys, name) {
+			return nil, errors.Errorf("invalid sort key %q, must be one of %s", name, strings.Join(SortKeys, "|"))
+		}
+		key := SortKey{Key: name, Desc: defaultDesc}
+		if hasDir {
+			if key.Desc, err = parseOrder(dir); err != nil {
+				return nil, err
+			}
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		keys = []SortKey{{Key: SortByRange, Desc: defaultDesc}}
+	}
+	return keys, nil
+}
+
+func parseOrder(order string) (bool, error) {
+	switch strings.ToLower(order) {
+	case Asc, "":
+		return false, nil
+	case Desc:
+		return true, nil
+	}
+	return false, errors.Errorf("invalid sort order %q, must be %s|%s", order, Asc, Desc)
+}
+
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// sortValue the value advice is sorted by, az is empty when the advice is sorted as a whole.
+// ok is false for missing values, e.g. no score or no price
+func sortValue(key string, advice *models.Advice, az string) (num float64, str string, ok bool) {
+	switch key {
+	case SortByRange:
+		return float64(advice.Range.Min), "", true
+	case SortByInstance:
+		return 0, advice.Instance, true
+	case SortBySavings:
+		return float64(advice.Savings), "", true
+	case SortByPrice:
+		return advice.Price, "", advice.Price > 0
+	case SortByRegion:
+		return 0, advice.Region, true
+	case SortByZone:
+		return 0, az, az != ""
+	case SortByScore:
+		if az != "" {
+			scores, ok := advice.Score[az]
+			return float64(scores.Min()), "", ok && len(scores) > 0
+		}
+		// the best AZ stands for the advice
+		best, found := 0, false
+		for _, scores := range advice.Score {
+			if len(scores) > 0 && (!found || scores.Min() > best) {
+				best, found = scores.Min(), true
+			}
+		}
+		return float64(best), "", found
+	case SortByZonePrice:
+		price := zonePrice(advice, az)
+		return price, "", price > 0
+	case SortByPricePerCPU:
+		return advice.Price / float64(advice.Info.Cores), "", advice.Price > 0 && advice.Info.Cores > 0
+	case SortByPricePerGiB:
+		return advice.Price / float64(advice.Info.RAM), "", advice.Price > 0 && advice.Info.RAM > 0
+	}
+	return 0, "", false
+}
+
+// zonePrice the price in az, the cheapest AZ when az is empty, the region price when there is no AZ price
+func zonePrice(advice *models.Advice, az string) float64 {
+	if az != "" {
+		if price, ok := advice.ZonePrice[az]; ok && price > 0 {
+			return price
+		}
+		return advice.Price
+	}
+	cheapest := 0.0
+	for _, price := range advice.ZonePrice {
+		if price > 0 && (cheapest == 0 || price < cheapest) {
+			cheapest = price
+		}
+	}
+	if cheapest == 0 {
+		return advice.Price
+	}
+	return cheapest
+}
+
+// CompareAdvices compare advice a in azA with advice b in azB by keys, AZs are empty to compare
+// whole advices. Missing values sort last in both orders, ties are broken by region, type and AZ
+func CompareAdvices(keys []SortKey, a *models.Advice, azA string, b *models.Advice, azB string) int {
+	for _, key := range keys {
+		if c := compareValues(key.Key, a, azA, b, azB); c != 0 {
+			if key.Desc {
+				// only present values are reversed, missing ones stay last
+				if _, _, ok := sortValue(key.Key, a, azA); ok {
+					if _, _, ok = sortValue(key.Key, b, azB); ok {
+						return -c
+					}
+				}
+			}
+			return c
+		}
+	}
+	for _, tie := range []string{SortByRegion, SortByInstance, SortByZone} {
+		if c := compareValues(tie, a, azA, b, azB); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func compareValues(key string, a *models.Advice, azA string, b *models.Advice, azB string) int {
+	numA, strA, okA := sortValue(key, a, azA)
+	numB, strB, okB := sortValue(key, b, azB)
+	switch {
+	case !okA && !okB:
+		return 0
+	case !okA:
+		return 1
+	case !okB:
+		return -1
+	case numA < numB:
+		return -1
+	case numA > numB:
+		return 1
+	}
+	return strings.Compare(strA, strB)
+}
+
+// SortAdvices sort advices by keys
+func SortAdvices(advices []models.Advice, keys []SortKey) {
+	sort.SliceStable(advices, func(i, j int) bool {
+		return CompareAdvices(keys, &advices[i], "", &advices[j], "") < 0
+	})
+}
@@ -0,0 +1,90 @@
+package aws
+
+import (
+	"reflect"
+	"spotinfo/pkg/models"
+	"testing"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		spec  string
+		order string
+		want  []SortKey
+		valid bool
+	}{
+		{spec: "", order: "desc", want: []SortKey{{Key: SortByRange, Desc: true}}, valid: true},
+		{spec: "score:desc,price:asc", order: "asc", want: []SortKey{{Key: SortByScore, Desc: true}, {Key: SortByPrice}}, valid: true},
+		{spec: "saving, Price-Per-vCPU", order: "desc", want: []SortKey{{Key: SortBySavings, Desc: true}, {Key: SortByPricePerCPU, Desc: true}}, valid: true},
+		{spec: "rage", order: "asc", want: []SortKey{{Key: SortByRange}}, valid: true},
+		{spec: "cost", order: "asc"},
+		{spec: "price:up", order: "asc"},
+		{spec: "price", order: "random"},
+	}
+	for _, tt := range tests {
+		got, err := ParseSort(tt.spec, tt.order)
+		if (err == nil) != tt.valid {
+			t.Errorf("%q %q: got error %v, want valid %v", tt.spec, tt.order, err, tt.valid)
+			continue
+		}
+		if tt.valid && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q %q: got %v, want %v", tt.spec, tt.order, got, tt.want)
+		}
+	}
+}
+
+func TestSortAdvices(t *testing.T) {
+	advices := func() []models.Advice {
+		return []models.Advice{
+			{Region: "us-east-1", Instance: "a", Price: 0.04, Info: models.TypeInfo{Cores: 2, RAM: 8},
+				Score: map[string]models.LifetimeScores{"us-east-1a": {1: 80}, "us-east-1b": {1: 20}}},
+			{Region: "us-east-1", Instance: "b", Price: 0.06, Info: models.TypeInfo{Cores: 4, RAM: 4},
+				Score: map[string]models.LifetimeScores{"us-east-1a": {1: 80, 4: 60}}},
+			{Region: "us-east-1", Instance: "c", Price: 0.02, Info: models.TypeInfo{Cores: 1, RAM: 2}},
+			{Region: "us-east-1", Instance: "d", Info: models.TypeInfo{Cores: 2, RAM: 4},
+				Score: map[string]models.LifetimeScores{"us-east-1a": {1: 90}}},
+		}
+	}
+	tests := []struct {
+		spec string
+		want []string
+	}{
+		// the best AZ counts, b scores 60 over all lifetimes, c has no score
+		{spec: "score:desc,price:asc", want: []string{"d", "a", "b", "c"}},
+		// d has no price and stays last in both orders
+		{spec: "price:asc", want: []string{"c", "a", "b", "d"}},
+		{spec: "price:desc", want: []string{"b", "a", "c", "d"}},
+		{spec: "price-per-vcpu:asc", want: []string{"b", "a", "c", "d"}},
+		{spec: "price-per-gib:asc", want: []string{"a", "c", "b", "d"}},
+	}
+	for _, tt := range tests {
+		keys, err := ParseSort(tt.spec, "asc")
+		if err != nil {
+			t.Fatal(err)
+		}
+		sorted := advices()
+		SortAdvices(sorted, keys)
+		var got []string
+		for _, advice := range sorted {
+			got = append(got, advice.Instance)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestCompareAdvicesPerZone(t *testing.T) {
+	advice := &models.Advice{Region: "us-east-1", Instance: "a", Price: 0.04,
+		Score:     map[string]models.LifetimeScores{"us-east-1a": {1: 80}, "us-east-1b": {1: 20}},
+		ZonePrice: map[string]float64{"us-east-1a": 0.05}}
+	keys := []SortKey{{Key: SortByScore, Desc: true}}
+	if c := CompareAdvices(keys, advice, "us-east-1a", advice, "us-east-1b"); c >= 0 {
+		t.Errorf("score: us-east-1a should come first, got %d", c)
+	}
+	// us-east-1b has no AZ price and falls back to the region price
+	keys = []SortKey{{Key: SortByZonePrice}}
+	if c := CompareAdvices(keys, advice, "us-east-1a", advice, "us-east-1b"); c <= 0 {
+		t.Errorf("az-price: us-east-1b should come first, got %d", c)
+	}
+}
@@ -0,0 +1,289 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"github.com/bytedance/sonic"
+	"os"
+	"path/filepath"
+	"spotinfo/pkg/httpclient"
+	"spotinfo/pkg/known"
+	"spotinfo/pkg/models"
+	"spotinfo/pkg/options"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	advisorTimeout = 10 * time.Second
+	priceTimeout   = 1 * time.Minute
+)
+
+// file names of the feed snapshots read by FileSource
+const (
+	AdvisorSnapshotFile = "spot-advisor-data.json"
+	PriceSnapshotFile   = "spot.js"
+	ScoreSnapshotFile   = "score.json"
+	ZonesSnapshotFile   = "zones.json"
+)
+
+// Source provides the feeds the analyzer works on: AWS spot advisor data,
+// AWS spot pricing and Spotinst market scores
+type Source interface {
+	// Advisor returns the spot advisor data: interruption ranges, savings and instance type details
+	Advisor(ctx context.Context) (*models.AdvisorData, error)
+	// Price returns the spot price of every instance type per region
+	Price(ctx context.Context) (*models.SpotPriceData, error)
+	// Score returns the Spotinst market scores of the requested instance types and AZs,
+	// on partial failures the scores fetched are returned along with ScoreErrors
+	Score(ctx context.Context, req *ScoreRequest) ([]models.SpotinstScore, error)
+	// Zones returns the availability zones of a region
+	Zones(ctx context.Context, region string) ([]string, error)
+}
+
+// StatusReporter implemented by sources knowing how fresh the feeds they served are
+type StatusReporter interface {
+	FeedStatus() []models.FeedStatus
+}
+
+// ScoreRequest market score query
+type ScoreRequest struct {
+	Region    string
+	Instances []string
+	Azs       []string
+	// Lifetimes minimum instance lifetimes in hours, DefaultLifetimes when empty
+	Lifetimes []int
+	// Product EC2 product scored, the Linux product when empty
+	Product string
+}
+
+func (r *ScoreRequest) product() string {
+	if r.Product == "" {
+		return known.ScoreProducts[known.LinuxOS]
+	}
+	return r.Product
+}
+
+func (r *ScoreRequest) lifetimes() []int {
+	if len(r.Lifetimes) == 0 {
+		return DefaultLifetimes
+	}
+	return r.Lifetimes
+}
+
+// key identify the region, product and lifetimes of the request, e.g. us-east-1-linux-unix-amazon-vpc-1h-4h
+func (r *ScoreRequest) key() string {
+	key := r.Region + "-" + productKey(r.product())
+	for _, lifetime := range r.lifetimes() {
+		key += fmt.Sprintf("-%dh", lifetime)
+	}
+	return key
+}
+
+// productKey the product lower cased with runs of other characters than letters and digits replaced by a dash
+func productKey(product string) string {
+	var b strings.Builder
+	dash := false
+	for _, c := range strings.ToLower(product) {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(c)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// NewSource create the source selected by opts
+func NewSource(opts *options.SpotinstOptions) (Source, error) {
+	switch opts.Source {
+	case known.HTTPSource, "":
+		c := NewCache(opts)
+		src := NewHTTPSource()
+		src.Spotinst = NewSpotinstAccount(opts, c)
+		return NewCachedSource(src, c, opts.MaxStale), nil
+	case known.FileSource:
+		if opts.SourceDir == "" {
+			return nil, errors.New("--source-dir is required for the file source")
+		}
+		return &FileSource{Dir: opts.SourceDir}, nil
+	default:
+		return nil, errors.Errorf("invalid source %q, must be %s|%s", opts.Source, known.HTTPSource, known.FileSource)
+	}
+}
+
+// HTTPSource reads the feeds from AWS and the Spotinst console
+type HTTPSource struct {
+	AdvisorURL string
+	PriceURL   string
+	Client     *httpclient.Client
+	Spotinst   *SpotinstAccount
+}
+
+// NewHTTPSource create a source reading the public AWS feeds
+func NewHTTPSource() *HTTPSource {
+	return &HTTPSource{
+		AdvisorURL: known.SpotAdvisorJSONURL,
+		PriceURL:   known.SpotPriceJsURL,
+	}
+}
+
+func (s *HTTPSource) Advisor(ctx context.Context) (*models.AdvisorData, error) {
+	return dataLazyLoad(ctx, clientOrDefault(s.Client), s.AdvisorURL, advisorTimeout)
+}
+
+func (s *HTTPSource) Price(ctx context.Context) (*models.SpotPriceData, error) {
+	raw, err := pricingLazyLoad(ctx, clientOrDefault(s.Client), s.PriceURL, priceTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return convertRawData(raw), nil
+}
+
+func (s *HTTPSource) Score(ctx context.Context, req *ScoreRequest) ([]models.SpotinstScore, error) {
+	if s.Spotinst == nil || s.Spotinst.Tokens == nil {
+		return nil, ErrNoCredentials
+	}
+	scs, err := getSpotinstScores(ctx, req, s.Spotinst)
+	return scs.SS, err
+}
+
+func (s *HTTPSource) Zones(ctx context.Context, region string) ([]string, error) {
+	return getSpotinstZones(ctx, s.Spotinst, region)
+}
+
+// FileSource reads the feeds from snapshots captured on disk: the advisor
+// JSON and pricing JS exactly as served by AWS, the scores as a JSON list and
+// the zones as a JSON object of region to AZ list
+type FileSource struct {
+	Dir string
+}
+
+func (s *FileSource) read(name string) ([]byte, error) {
+	content, err := os.ReadFile(filepath.Join(s.Dir, name))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read snapshot")
+	}
+	return content, nil
+}
+
+func (s *FileSource) Advisor(_ context.Context) (*models.AdvisorData, error) {
+	content, err := s.read(AdvisorSnapshotFile)
+	if err != nil {
+		return nil, err
+	}
+	return parseAdvisorData(content)
+}
+
+func (s *FileSource) Price(_ context.Context) (*models.SpotPriceData, error) {
+	content, err := s.read(PriceSnapshotFile)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := parseRawPriceData(content)
+	if err != nil {
+		return nil, err
+	}
+	return convertRawData(raw), nil
+}
+
+func (s *FileSource) Score(_ context.Context, req *ScoreRequest) ([]models.SpotinstScore, error) {
+	content, err := s.read(ScoreSnapshotFile)
+	if err != nil {
+		return nil, err
+	}
+	var scores []models.SpotinstScore
+	if err = sonic.Unmarshal(content, &scores); err != nil {
+		return nil, errors.Wrap(err, "failed to parse score snapshot")
+	}
+	// snapshots without lifetime or product hold 1 hour Linux scores
+	for i := range scores {
+		if scores[i].Lifetime == 0 {
+			scores[i].Lifetime = 1
+		}
+		if scores[i].Product == "" {
+			scores[i].Product = known.ScoreProducts[known.LinuxOS]
+		}
+	}
+	return filterScores(scores, req), nil
+}
+
+func (s *FileSource) Zones(_ context.Context, region string) ([]string, error) {
+	content, err := s.read(ZonesSnapshotFile)
+	if err != nil {
+		return nil, err
+	}
+	var zones map[string][]string
+	if err = sonic.Unmarshal(content, &zones); err != nil {
+		return nil, errors.Wrap(err, "failed to parse zones snapshot")
+	}
+	azs, ok := zones[region]
+	if !ok {
+		return nil, errors.Errorf("no zones for region %s in snapshot", region)
+	}
+	return azs, nil
+}
+
+// MemorySource serves feeds held in memory, mostly useful to inject fakes in tests
+type MemorySource struct {
+	AdvisorData *models.AdvisorData
+	PriceData   *models.SpotPriceData
+	Scores      []models.SpotinstScore
+	// ZoneData AZs per region
+	ZoneData map[string][]string
+}
+
+func (s *MemorySource) Advisor(_ context.Context) (*models.AdvisorData, error) {
+	if s.AdvisorData == nil {
+		return nil, errors.New("no advisor data")
+	}
+	return s.AdvisorData, nil
+}
+
+func (s *MemorySource) Price(_ context.Context) (*models.SpotPriceData, error) {
+	if s.PriceData == nil {
+		return nil, errors.New("no pricing data")
+	}
+	return s.PriceData, nil
+}
+
+func (s *MemorySource) Score(_ context.Context, req *ScoreRequest) ([]models.SpotinstScore, error) {
+	return filterScores(s.Scores, req), nil
+}
+
+func (s *MemorySource) Zones(_ context.Context, region string) ([]string, error) {
+	azs, ok := s.ZoneData[region]
+	if !ok {
+		return nil, errors.Errorf("no zones for region %s", region)
+	}
+	return azs, nil
+}
+
+// filterScores keep the scores matching the instance types, AZs, lifetimes and product of req
+func filterScores(scores []models.SpotinstScore, req *ScoreRequest) []models.SpotinstScore {
+	instances := make(map[string]bool, len(req.Instances))
+	for _, instance := range req.Instances {
+		instances[instance] = true
+	}
+	azs := make(map[string]bool, len(req.Azs))
+	for _, az := range req.Azs {
+		azs[az] = true
+	}
+	lifetimes := make(map[int]bool)
+	for _, lifetime := range req.lifetimes() {
+		lifetimes[lifetime] = true
+	}
+	var result []models.SpotinstScore
+	for _, sc := range scores {
+		if instances[sc.InstanceType] && azs[sc.Az] && lifetimes[sc.Lifetime] && sc.Product == req.product() {
+			result = append(result, sc)
+		}
+	}
+	return result
+}
@@ -0,0 +1,28 @@
+package aws
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFileSourceZones(t *testing.T) {
+	dir := t.TempDir()
+	content := []byte(`{"us-east-1": ["us-east-1a", "us-east-1b"]}`)
+	if err := os.WriteFile(filepath.Join(dir, ZonesSnapshotFile), content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	src := &FileSource{Dir: dir}
+	azs, err := src.Zones(context.Background(), "us-east-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(azs, []string{"us-east-1a", "us-east-1b"}) {
+		t.Errorf("unexpected zones %v", azs)
+	}
+	if _, err = src.Zones(context.Background(), "eu-west-1"); err == nil {
+		t.Error("expected a missing region error")
+	}
+}
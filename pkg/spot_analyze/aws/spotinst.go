@@ -2,38 +2,93 @@ package aws
 
 import (
 	"context"
-	"crypto/tls"
-	"errors"
 	"fmt"
 	"github.com/bytedance/sonic"
-	"github.com/cloudwego/hertz/pkg/app/client"
 	"github.com/cloudwego/hertz/pkg/protocol"
 	"github.com/cloudwego/hertz/pkg/protocol/consts"
 	"github.com/panjf2000/ants/v2"
 	"net/url"
 	"os"
+	"sort"
+	"spotinfo/pkg/cache"
+	"spotinfo/pkg/httpclient"
 	"spotinfo/pkg/known"
 	"spotinfo/pkg/models"
+	"spotinfo/pkg/options"
 	"sync"
 	"time"
+
+	"github.com/pkg/errors"
 )
 
-var (
-	loadScoreOnce sync.Once
-	spotScores    *spotScoreData
+const (
+	defaultScoreBatchSize   = 50
+	defaultScoreConcurrency = 10
 )
 
+// DefaultLifetimes minimum instance lifetimes in hours scored when none is given
+var DefaultLifetimes = []int{1}
+
 type instanceScore struct {
-	Instance map[string]int `json:"instance"`
+	Instance map[string]models.LifetimeScores `json:"instance"`
 }
+
+// spotScoreData scores of one product per AZ and instance type
 type spotScoreData struct {
 	Azs map[string]instanceScore `json:"azs"`
 }
 
 func getSpotinstScore(param interface{}) {
 	ap := param.(*AntsParams)
-	SpotinstAccessToken, _ := os.LookupEnv("SpotinstAccessToken")
-	uri, _ := url.JoinPath(known.SpotHost, known.SpotMarketScoreUri)
+	if _, err := ap.Tokens.Token(ap.Ctx); err != nil {
+		ap.Errs.add(&ScoreError{Batch: ap.Batch, Kind: ScoreErrAuth, Err: err})
+		return
+	}
+	status, body, err := withToken(ap.Ctx, ap.Tokens, func(token string) (int, []byte, error) {
+		return postSpotinstScore(ap, token)
+	})
+	if err != nil {
+		ap.Errs.add(&ScoreError{Batch: ap.Batch, Kind: ScoreErrNetwork, Err: err})
+		return
+	}
+	switch {
+	case status == consts.StatusUnauthorized || status == consts.StatusForbidden:
+		ap.Errs.add(&ScoreError{Batch: ap.Batch, Kind: ScoreErrAuth, StatusCode: status, Err: errors.New(string(body))})
+		return
+	case status != consts.StatusOK:
+		ap.Errs.add(&ScoreError{Batch: ap.Batch, Kind: ScoreErrStatus, StatusCode: status, Err: errors.New(string(body))})
+		return
+	}
+	var ssResp = &models.SpotinstScoreResp{}
+	err = sonic.Unmarshal(body, ssResp)
+	if err != nil {
+		ap.Errs.add(&ScoreError{Batch: ap.Batch, Kind: ScoreErrDecode, Err: errors.Wrapf(err, "parse spotinst score data failed %s", string(body))})
+		return
+	}
+	for _, item := range ssResp.Items {
+		for _, marketScore := range item.MarketsScore {
+			var ss = models.SpotinstScore{
+				InstanceType: marketScore.InstanceType,
+				Az:           marketScore.AvailabilityZone,
+				Score:        int(marketScore.Score),
+				Lifetime:     item.LifetimePeriod,
+				Product:      marketScore.Product,
+			}
+			if ss.Product == "" {
+				ss.Product = ap.Product
+			}
+			ap.Scs.Lock.Lock()
+			ap.Scs.SS = append(ap.Scs.SS, ss)
+			ap.Scs.Lock.Unlock()
+		}
+	}
+
+	return
+}
+
+// postSpotinstScore send one market score request, returns the response status and body
+func postSpotinstScore(ap *AntsParams, token string) (status int, body []byte, err error) {
+	uri, _ := url.JoinPath(ap.Endpoint, known.SpotMarketScoreUri)
 	req, resp := protocol.AcquireRequest(), protocol.AcquireResponse()
 	defer func() {
 		protocol.ReleaseRequest(req)
@@ -41,18 +96,15 @@ func getSpotinstScore(param interface{}) {
 	}()
 	req.SetMethod(consts.MethodPost)
 	req.SetRequestURI(uri)
-	req.SetQueryString(fmt.Sprintf("accountId=%s", known.SpotAccountId))
+	req.SetQueryString(fmt.Sprintf("accountId=%s", url.QueryEscape(ap.Account)))
 
 	var bodyMap = make(map[string]interface{}, 0)
 	bodyMap["availabilityZones"] = ap.Azs
 	bodyMap["instanceTypes"] = ap.Instances
-	bodyMap["product"] = "Linux/UNIX (Amazon VPC)"
-	bodyMap["minimumInstanceLifetime"] = []int{1}
-	//https://console.spotinst.com/api/aws/ec2/availabilityZone?accountId=act-4321e68e&region=us-east-3
-	//
+	bodyMap["product"] = ap.Product
+	bodyMap["minimumInstanceLifetime"] = ap.Lifetimes
 	requestBody, _ := sonic.Marshal(bodyMap)
 	req.SetBody(requestBody)
-	fmt.Println(string(requestBody))
 	req.SetHeaders(map[string]string{
 		"User-Agent": "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/115.0.0.0 Safari/537.36",
 		"Accept":     "application/json, text/plain, */*",
@@ -60,133 +112,276 @@ func getSpotinstScore(param interface{}) {
 		"Accept-Language": "en,zh-CN;q=0.9,zh;q=0.8",
 		"Content-Type":    "application/json;charset=UTF-8",
 	})
-	req.SetAuthToken(SpotinstAccessToken)
-	hClient, _ := client.NewClient(client.WithTLSConfig(&tls.Config{
-		InsecureSkipVerify: true,
-	}))
-
-	err := hClient.DoTimeout(ap.Ctx, req, resp, 30*time.Second)
-	if err != nil {
+	req.SetAuthToken(token)
+	if err = clientOrDefault(ap.Client).Do(ap.Ctx, req, resp, 30*time.Second); err != nil {
 		return
 	}
-	var ssResp = &models.SpotinstScoreResp{}
-	err = sonic.Unmarshal(resp.Body(), ssResp)
-	if err != nil {
-		err = errors.New(fmt.Sprintf("parse spotinst score data failed %s ", string(resp.Body())))
-		return
+	// the body is released with the response
+	body = append([]byte(nil), resp.Body()...)
+	return resp.StatusCode(), body, nil
+}
+
+// SpotinstAccount the Spotinst account and API host scores are requested
+// from, and how the requests are sent
+type SpotinstAccount struct {
+	ID       string
+	Endpoint string
+	Tokens   TokenProvider
+	Client   *httpclient.Client
+	// instance types per score request and concurrent score requests
+	ScoreBatchSize   int
+	ScoreConcurrency int
+}
+
+// NewSpotinstAccount the account configured by opts, tokens may be nil when no credentials were given
+func NewSpotinstAccount(opts *options.SpotinstOptions, c *cache.Cache) *SpotinstAccount {
+	client := httpclient.New(httpclient.Config{
+		Retries:    opts.Retries,
+		Backoff:    opts.RetryBackoff,
+		MaxBackoff: httpclient.DefaultConfig.MaxBackoff,
+		RateLimit:  opts.RateLimit,
+	})
+	// a missing token only matters to the calls needing it
+	tokens, _ := NewTokenProvider(opts, c, client)
+	return &SpotinstAccount{
+		ID:               opts.SpotinstAccount,
+		Endpoint:         opts.SpotinstEndpoint,
+		Tokens:           tokens,
+		Client:           client,
+		ScoreConcurrency: opts.ScoreConcurrency,
+		ScoreBatchSize:   opts.ScoreBatchSize,
 	}
-	for _, item := range ssResp.Items {
-		for _, marketScore := range item.MarketsScore {
-			var ss = models.SpotinstScore{
-				InstanceType: marketScore.InstanceType,
-				Az:           marketScore.AvailabilityZone,
-				Score:        int(marketScore.Score),
-			}
-			ap.Scs.Lock.Lock()
-			ap.Scs.SS = append(ap.Scs.SS, ss)
-			ap.Scs.Lock.Unlock()
-		}
+}
+
+func clientOrDefault(c *httpclient.Client) *httpclient.Client {
+	if c == nil {
+		return httpclient.Default()
 	}
+	return c
+}
 
-	return
+// ListSpotinstAccounts list the accounts the token can see
+func ListSpotinstAccounts(ctx context.Context, account *SpotinstAccount) ([]models.SpotinstAccount, error) {
+	if account.Tokens == nil {
+		return nil, ErrNoCredentials
+	}
+	uri, _ := url.JoinPath(account.Endpoint, known.SpotAccountsUri)
+	status, body, err := withToken(ctx, account.Tokens, func(token string) (int, []byte, error) {
+		req, resp := protocol.AcquireRequest(), protocol.AcquireResponse()
+		defer func() {
+			protocol.ReleaseRequest(req)
+			protocol.ReleaseResponse(resp)
+		}()
+		req.SetMethod(consts.MethodGet)
+		req.SetRequestURI(uri)
+		req.SetHeader("Accept", "application/json, text/plain, */*")
+		req.SetAuthToken(token)
+		if err := clientOrDefault(account.Client).Do(ctx, req, resp, 30*time.Second); err != nil {
+			return 0, nil, err
+		}
+		return resp.StatusCode(), append([]byte(nil), resp.Body()...), nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list spotinst accounts")
+	}
+	if status != consts.StatusOK {
+		return nil, errors.Errorf("failed to list spotinst accounts, code: %d, detail:%s", status, string(body))
+	}
+	var accountsResp models.SpotinstAccountsResp
+	if err = sonic.Unmarshal(body, &accountsResp); err != nil {
+		return nil, errors.Wrap(err, "failed to parse spotinst accounts")
+	}
+	return accountsResp.Response.Items, nil
 }
 
 type AntsParams struct {
 	Azs       []string
 	Ctx       context.Context
 	Instances []string
+	Lifetimes []int
+	Product   string
 	Scs       *models.SpotinstScores
+	Tokens    TokenProvider
+	Account   string
+	Endpoint  string
+	Batch     int
+	Errs      *scoreErrorCollector
+	Client    *httpclient.Client
 }
 
-func getSpotinstScores(ctx context.Context, instances []string) (scs *models.SpotinstScores, err error) {
-	batch := 50
+// getSpotinstScores request the scores in batches, failed batches are reported
+// as ScoreErrors along with the scores of the other batches
+func getSpotinstScores(ctx context.Context, scoreReq *ScoreRequest, account *SpotinstAccount) (scs *models.SpotinstScores, err error) {
+	batch, concurrency := account.ScoreBatchSize, account.ScoreConcurrency
+	if batch <= 0 {
+		batch = defaultScoreBatchSize
+	}
+	if concurrency <= 0 {
+		concurrency = defaultScoreConcurrency
+	}
 	var wg sync.WaitGroup
 	scs = &models.SpotinstScores{
 		Lock: sync.RWMutex{},
 		SS:   []models.SpotinstScore{},
 	}
-	var allAzs []string
-	{
-	}
-	for _, azs := range known.AvailablespotinstAzs {
-		allAzs = append(allAzs, azs...)
-	}
-	p, _ := ants.NewPoolWithFunc(10, func(params interface{}) {
+	errs := &scoreErrorCollector{}
+	p, _ := ants.NewPoolWithFunc(concurrency, func(params interface{}) {
 		getSpotinstScore(params)
 		wg.Done()
 	})
 	defer p.Release()
 
-	for i := 0; i < len(instances); i++ {
-		if i%batch == 0 && i > 0 || i == len(instances)-1 {
-			start := ((i / batch) - 1) * batch
-			end := start + batch
-			if i == len(instances)-1 {
-				start = (i / batch) * batch
-				end = len(instances)
-			}
-			// 并发
-			ap := &AntsParams{
-				Ctx:       ctx,
-				Azs:       allAzs,
-				Instances: instances[start:end],
-				Scs:       scs,
-			}
-			wg.Add(1)
-			_ = p.Invoke(ap)
+	for i, batchInstances := range chunk(scoreReq.Instances, batch) {
+		// 并发
+		ap := &AntsParams{
+			Ctx:       ctx,
+			Azs:       scoreReq.Azs,
+			Instances: batchInstances,
+			Lifetimes: scoreReq.lifetimes(),
+			Product:   scoreReq.product(),
+			Scs:       scs,
+			Tokens:    account.Tokens,
+			Account:   account.ID,
+			Endpoint:  account.Endpoint,
+			Batch:     i,
+			Errs:      errs,
+			Client:    account.Client,
+		}
+		wg.Add(1)
+		if err := p.Invoke(ap); err != nil {
+			wg.Done()
+			errs.add(&ScoreError{Batch: i, Kind: ScoreErrNetwork, Err: errors.Wrap(err, "failed to submit score batch")})
 		}
 	}
 
 	wg.Wait()
 
-	return
+	return scs, errs.err()
 }
 
-func getSpotInstanceScore(ctx context.Context, instance, az string, data *models.AdvisorData) (score int, err error) {
-	loadScoreOnce.Do(func() {
-		awsScoreJsonPath := "/tmp/score.json"
-		if f, err := os.Stat(awsScoreJsonPath); err == nil && f.Size() >= 100 {
-			// 判断文件时间
-			if time.Now().Sub(f.ModTime()) < 24*time.Hour {
-				// 从文件中加载数据
-				fmt.Println("load score data from cache...")
-				content, _ := os.ReadFile(awsScoreJsonPath)
-				err = sonic.Unmarshal(content, &spotScores)
-				return
+// loadScores load the market scores of every instance type offered in the region of scoreReq for its product,
+// in the requested AZs, once per region, product and lifetimes.
+// The scores of a partially failed load are kept, the error is still returned
+func (a *Analyzer) loadScores(ctx context.Context, scoreReq *ScoreRequest) error {
+	if err := a.loadData(ctx); err != nil {
+		return err
+	}
+	a.scoreLock.Lock()
+	defer a.scoreLock.Unlock()
+	key := scoreReq.key()
+	if err, ok := a.scoreErrs[key]; ok {
+		return err
+	}
+	// the advisor feed lists windows and linux offers, rhel and suse run on the linux ones
+	offers := a.data.Regions[scoreReq.Region].Linux
+	if scoreReq.product() == known.ScoreProducts[known.WindowsOS] {
+		offers = a.data.Regions[scoreReq.Region].Windows
+	}
+	instances := make([]string, 0, len(offers))
+	for k := range offers {
+		instances = append(instances, k)
+	}
+	sort.Strings(instances)
+	scoreReq.Instances = instances
+	scores, err := a.source.Score(ctx, scoreReq)
+	if err != nil {
+		a.scoreErrs[key] = errors.Wrapf(err, "failed to load spotinst market scores of %s", scoreReq.Region)
+	} else {
+		a.scoreErrs[key] = nil
+	}
+	data, ok := a.spotScores[scoreReq.product()]
+	if !ok {
+		data = &spotScoreData{Azs: make(map[string]instanceScore)}
+		a.spotScores[scoreReq.product()] = data
+	}
+	for _, sc := range scores {
+		if _, ok := data.Azs[sc.Az]; !ok {
+			data.Azs[sc.Az] = instanceScore{
+				Instance: make(map[string]models.LifetimeScores, 0),
 			}
-		} else {
-			os.Create(awsScoreJsonPath)
-		}
-		fmt.Println("missing score cache, load from remote...")
-		// 获取所有region/instance
-		var allRegion, allInstance []string
-		for k := range data.Regions {
-			allRegion = append(allRegion, k)
 		}
-		for k := range data.InstanceTypes {
-			allInstance = append(allInstance, k)
+		if _, ok := data.Azs[sc.Az].Instance[sc.InstanceType]; !ok {
+			data.Azs[sc.Az].Instance[sc.InstanceType] = make(models.LifetimeScores)
 		}
-		scs, err := getSpotinstScores(ctx, allInstance)
-		if err != nil {
-			return
-		}
-		spotScores = &spotScoreData{}
-		spotScores.Azs = make(map[string]instanceScore, 0)
-		for _, sc := range scs.SS {
-			//score := make(map[string]float64, 0)
-			if _, ok := spotScores.Azs[sc.Az]; !ok {
-				spotScores.Azs[sc.Az] = instanceScore{
-					Instance: make(map[string]int, 0),
-				}
-			}
-			spotScores.Azs[sc.Az].Instance[sc.InstanceType] = sc.Score
-		}
-		contentByte, err := sonic.Marshal(spotScores)
-		if err != nil {
-			fmt.Println(err)
+		data.Azs[sc.Az].Instance[sc.InstanceType][sc.Lifetime] = sc.Score
+	}
+	return a.scoreErrs[key]
+}
+
+// getZones the AZs of region, the static zone table is used when discovery fails.
+// Discovery runs outside scoreLock, the first result published for region wins
+func (a *Analyzer) getZones(ctx context.Context, region string) []string {
+	a.scoreLock.Lock()
+	azs, ok := a.zones[region]
+	a.scoreLock.Unlock()
+	if ok {
+		return azs
+	}
+	azs, err := a.source.Zones(ctx, region)
+	discovered := err == nil && len(azs) > 0
+	if !discovered {
+		azs = known.AvailablespotinstAzs[region]
+	}
+	a.scoreLock.Lock()
+	defer a.scoreLock.Unlock()
+	if published, ok := a.zones[region]; ok {
+		return published
+	}
+	if !discovered {
+		fmt.Fprintf(os.Stderr, "discover %s zones failed, use the static zone list: %v\n", region, err)
+		a.warnings = append(a.warnings, fmt.Sprintf("%s zones not discovered, static zone list used", region))
+	}
+	a.zones[region] = azs
+	return azs
+}
+
+func (a *Analyzer) getSpotInstanceScore(product, instance, az string) (score models.LifetimeScores, ok bool) {
+	data, ok := a.spotScores[product]
+	if !ok {
+		return nil, false
+	}
+	score, ok = data.Azs[az].Instance[instance]
+	return
+}
+
+// getSpotinstZones discover the AZs of region
+func getSpotinstZones(ctx context.Context, account *SpotinstAccount, region string) ([]string, error) {
+	if account == nil || account.Tokens == nil {
+		return nil, ErrNoCredentials
+	}
+	uri, _ := url.JoinPath(account.Endpoint, known.SpotAvailabilityZoneUri)
+	status, body, err := withToken(ctx, account.Tokens, func(token string) (int, []byte, error) {
+		req, resp := protocol.AcquireRequest(), protocol.AcquireResponse()
+		defer func() {
+			protocol.ReleaseRequest(req)
+			protocol.ReleaseResponse(resp)
+		}()
+		req.SetMethod(consts.MethodGet)
+		req.SetRequestURI(uri)
+		req.SetQueryString(fmt.Sprintf("accountId=%s&region=%s", url.QueryEscape(account.ID), url.QueryEscape(region)))
+		req.SetHeader("Accept", "application/json, text/plain, */*")
+		req.SetAuthToken(token)
+		if err := clientOrDefault(account.Client).Do(ctx, req, resp, 30*time.Second); err != nil {
+			return 0, nil, err
 		}
-		os.WriteFile(awsScoreJsonPath, contentByte, 0644)
+		return resp.StatusCode(), append([]byte(nil), resp.Body()...), nil
 	})
-	score = spotScores.Azs[az].Instance[instance]
-	return
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to discover availability zones")
+	}
+	if status != consts.StatusOK {
+		return nil, errors.Errorf("failed to discover availability zones, code: %d, detail:%s", status, string(body))
+	}
+	var zonesResp models.SpotinstZonesResp
+	if err = sonic.Unmarshal(body, &zonesResp); err != nil {
+		return nil, errors.Wrap(err, "failed to parse availability zones")
+	}
+	var azs []string
+	for _, zone := range zonesResp.Response.Items {
+		if zone.Name != "" {
+			azs = append(azs, zone.Name)
+		}
+	}
+	sort.Strings(azs)
+	return azs, nil
 }
@@ -0,0 +1,104 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"spotinfo/pkg/httpclient"
+	"spotinfo/pkg/known"
+	"sync/atomic"
+	"testing"
+)
+
+func TestHTTPSourceScore(t *testing.T) {
+	var signIns int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case known.SpotSignUri:
+			fmt.Fprintf(w, `{"response":{"items":[{"accessToken":"token-%d"}]}}`, atomic.AddInt32(&signIns, 1))
+		case known.SpotMarketScoreUri:
+			if r.URL.Query().Get("accountId") != "act-test" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			// the first token is rejected
+			if r.Header.Get("Authorization") != "Bearer token-2" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"kind":"spotinst:aws:ec2:market:score","items":[{"lifetimePeriod":1,"marketsScore":[
+{"availabilityZone":"us-east-1a","instanceType":"m5.large","product":"Linux/UNIX (Amazon VPC)","score":81.5},
+{"availabilityZone":"us-east-1b","instanceType":"m5.large","product":"Linux/UNIX (Amazon VPC)","score":42}]}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	src := NewHTTPSource()
+	src.Spotinst = &SpotinstAccount{
+		ID:       "act-test",
+		Endpoint: srv.URL,
+		Tokens:   &SignInTokenProvider{UserName: "user", Password: "secret", Host: srv.URL},
+	}
+	scores, err := src.Score(context.Background(), &ScoreRequest{
+		Instances: []string{"m5.large"},
+		Azs:       []string{"us-east-1a", "us-east-1b"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scores) != 2 {
+		t.Fatalf("got %d scores, want 2", len(scores))
+	}
+	if signIns != 2 {
+		t.Errorf("got %d sign ins, want 2", signIns)
+	}
+	for _, sc := range scores {
+		if sc.Az == "us-east-1a" && sc.Score != 81 {
+			t.Errorf("us-east-1a: got %d, want 81", sc.Score)
+		}
+	}
+}
+
+func TestHTTPSourceScoreErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		kind   string
+	}{
+		{name: "forbidden", status: http.StatusForbidden, kind: ScoreErrAuth},
+		{name: "server error", status: http.StatusInternalServerError, kind: ScoreErrStatus},
+		{name: "invalid body", status: http.StatusOK, body: "<html>", kind: ScoreErrDecode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer srv.Close()
+
+			src := NewHTTPSource()
+			src.Spotinst = &SpotinstAccount{
+				ID:       "act-test",
+				Endpoint: srv.URL,
+				Tokens:   StaticToken("token"),
+				Client:   httpclient.New(httpclient.Config{}),
+			}
+			_, err := src.Score(context.Background(), &ScoreRequest{
+				Instances: []string{"m5.large", "c5.large"},
+				Azs:       []string{"us-east-1a"},
+			})
+			errs, ok := err.(ScoreErrors)
+			if !ok || len(errs) != 1 {
+				t.Fatalf("got %v, want one ScoreErrors", err)
+			}
+			if errs[0].Kind != tt.kind || errs[0].Batch != 0 {
+				t.Errorf("got %+v, want a %s error of batch 0", errs[0], tt.kind)
+			}
+		})
+	}
+}